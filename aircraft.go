@@ -10,14 +10,19 @@ import (
 
 type (
 	AircraftClient interface {
-		ListAircraft(ctx context.Context) *Iter[Aircraft]
+		ListAircraft(ctx context.Context, params ...ListAircraftParams) *Iter[Aircraft]
 		GetAircraft(ctx context.Context, id string) (*Aircraft, error)
 	}
+
+	ListAircraftParams struct {
+		ListOptions
+	}
 )
 
-func (a *API) ListAircraft(ctx context.Context) *Iter[Aircraft] {
-	return newRequestWithAPI[ListAirportsParams, Aircraft](a).
+func (a *API) ListAircraft(ctx context.Context, params ...ListAircraftParams) *Iter[Aircraft] {
+	return newRequestWithAPI[ListAircraftParams, Aircraft](a).
 		Get("/air/aircraft").
+		WithParams(normalizeParams(params)...).
 		Iter(ctx)
 }
 
@@ -10,14 +10,19 @@ import (
 
 type (
 	AirlinesClient interface {
-		ListAirlines(ctx context.Context) *Iter[Airline]
+		ListAirlines(ctx context.Context, params ...ListAirlinesParams) *Iter[Airline]
 		GetAirline(ctx context.Context, id string) (*Airline, error)
 	}
+
+	ListAirlinesParams struct {
+		ListOptions
+	}
 )
 
-func (a *API) ListAirlines(ctx context.Context) *Iter[Airline] {
-	return newRequestWithAPI[EmptyPayload, Airline](a).
+func (a *API) ListAirlines(ctx context.Context, params ...ListAirlinesParams) *Iter[Airline] {
+	return newRequestWithAPI[ListAirlinesParams, Airline](a).
 		Get("/air/airlines").
+		WithParams(normalizeParams(params)...).
 		Iter(ctx)
 }
 
@@ -16,6 +16,7 @@ type (
 	}
 
 	ListAirportsParams struct {
+		ListOptions
 		IATACountryCode string `url:"iata_country_code,omitempty"`
 	}
 )
@@ -0,0 +1,234 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bojanz/currency"
+)
+
+type (
+	// BaggageAllowance is the baggage a passenger can bring on a slice.
+	BaggageAllowance struct {
+		PassengerID string
+		SliceID     string
+		// SliceIndex is the position of the slice in the offer or order.
+		SliceIndex int
+
+		// Checked and CarryOn are the bags included on every segment of the slice.
+		// When segments include different allowances, such as on itineraries with
+		// several carriers, these are the smallest allowance of any segment.
+		Checked int
+		CarryOn int
+		// Varies is true if the segments of the slice include different allowances.
+		Varies bool
+
+		Segments []SegmentBaggage
+		// Purchasable are the extra bags that can be bought for the passenger on this slice.
+		Purchasable []*PurchasableBag
+	}
+
+	// SegmentBaggage is the baggage included for a passenger on a single segment.
+	SegmentBaggage struct {
+		SegmentID string
+		Checked   int
+		CarryOn   int
+	}
+
+	// PurchasableBag is an extra bag available to buy as a service.
+	PurchasableBag struct {
+		ServiceID       string
+		Type            string
+		MaximumQuantity int
+		Price           currency.Amount
+		MaximumWeightKg int
+		MaximumLengthCM int
+		MaximumHeightCM int
+		MaximumDepthCM  int
+		// SegmentIDs are the segments the bag covers.
+		SegmentIDs []string
+	}
+)
+
+const (
+	BaggageTypeChecked = "checked"
+	BaggageTypeCarryOn = "carry_on"
+
+	ServiceTypeBaggage = "baggage"
+)
+
+// BaggageSummary returns the baggage allowance of each passenger on each slice of
+// the offer, along with the extra bags available to buy. Purchasable bags are only
+// listed if the offer was fetched with its available services.
+func (o *Offer) BaggageSummary() []*BaggageAllowance {
+	ids := make([]string, len(o.Passengers))
+	for i, p := range o.Passengers {
+		ids[i] = p.ID
+	}
+	return summarizeBaggage(o.Slices, ids, o.AvailableServices)
+}
+
+// BaggageSummary returns the baggage allowance of each passenger on each slice of the order.
+func (o *Order) BaggageSummary() []*BaggageAllowance {
+	ids := make([]string, len(o.Passengers))
+	for i, p := range o.Passengers {
+		ids[i] = p.ID
+	}
+	return summarizeBaggage(o.Slices, ids, nil)
+}
+
+// String describes the included bags, e.g. "1 checked bag and 1 carry-on bag included".
+func (a *BaggageAllowance) String() string {
+	var parts []string
+	if a.Checked > 0 {
+		parts = append(parts, pluralize(a.Checked, "checked bag"))
+	}
+	if a.CarryOn > 0 {
+		parts = append(parts, pluralize(a.CarryOn, "carry-on bag"))
+	}
+	if len(parts) == 0 {
+		return "No bags included"
+	}
+	return strings.Join(parts, " and ") + " included"
+}
+
+// Dimensions describes the size and weight limits of the bag, e.g. "23kg, 90 x 75 x 43cm".
+func (b *PurchasableBag) Dimensions() string {
+	var parts []string
+	if b.MaximumWeightKg > 0 {
+		parts = append(parts, fmt.Sprintf("%dkg", b.MaximumWeightKg))
+	}
+	if b.MaximumLengthCM > 0 && b.MaximumHeightCM > 0 && b.MaximumDepthCM > 0 {
+		parts = append(parts, fmt.Sprintf("%d x %d x %dcm", b.MaximumLengthCM, b.MaximumHeightCM, b.MaximumDepthCM))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func summarizeBaggage(slices []Slice, passengerIDs []string, services []AvailableService) []*BaggageAllowance {
+	if len(passengerIDs) == 0 {
+		passengerIDs = segmentPassengerIDs(slices)
+	}
+
+	summary := make([]*BaggageAllowance, 0, len(passengerIDs)*len(slices))
+	for _, pid := range passengerIDs {
+		for i := range slices {
+			slice := &slices[i]
+			allowance := &BaggageAllowance{
+				PassengerID: pid,
+				SliceID:     slice.ID,
+				SliceIndex:  i,
+			}
+
+			for j, segment := range slice.Segments {
+				sb := SegmentBaggage{SegmentID: segment.ID}
+				for _, p := range segment.Passengers {
+					if p.ID != pid {
+						continue
+					}
+					sb.Checked, sb.CarryOn = countBags(p.Baggages)
+				}
+				allowance.Segments = append(allowance.Segments, sb)
+
+				if j == 0 {
+					allowance.Checked, allowance.CarryOn = sb.Checked, sb.CarryOn
+					continue
+				}
+				if sb.Checked != allowance.Checked || sb.CarryOn != allowance.CarryOn {
+					allowance.Varies = true
+				}
+				allowance.Checked = minInt(allowance.Checked, sb.Checked)
+				allowance.CarryOn = minInt(allowance.CarryOn, sb.CarryOn)
+			}
+
+			allowance.Purchasable = purchasableBags(slice, pid, services)
+			summary = append(summary, allowance)
+		}
+	}
+	return summary
+}
+
+func purchasableBags(slice *Slice, passengerID string, services []AvailableService) []*PurchasableBag {
+	segmentIDs := make([]string, len(slice.Segments))
+	for i, segment := range slice.Segments {
+		segmentIDs[i] = segment.ID
+	}
+
+	bags := make([]*PurchasableBag, 0)
+	for _, s := range services {
+		if s.Type != ServiceTypeBaggage || !containsString(s.PassengerIDs, passengerID) || !overlaps(s.SegmentIDs, segmentIDs) {
+			continue
+		}
+
+		price, err := currency.NewAmount(s.RawTotalAmount, s.RawTotalCurrency)
+		if err != nil {
+			// A bag without a valid price can't be offered for sale.
+			continue
+		}
+		bags = append(bags, &PurchasableBag{
+			ServiceID:       s.ID,
+			Type:            s.Metadata.Type,
+			MaximumQuantity: s.MaximumQuantity,
+			Price:           price,
+			MaximumWeightKg: s.Metadata.MaximumWeightKg,
+			MaximumLengthCM: s.Metadata.MaximumLengthCM,
+			MaximumHeightCM: s.Metadata.MaximumHeightCM,
+			MaximumDepthCM:  s.Metadata.MaximumDepthCM,
+			SegmentIDs:      s.SegmentIDs,
+		})
+	}
+	return bags
+}
+
+// segmentPassengerIDs returns the IDs of the passengers on the segments, in the order they first appear.
+func segmentPassengerIDs(slices []Slice) []string {
+	var ids []string
+	for _, slice := range slices {
+		for _, segment := range slice.Segments {
+			for _, p := range segment.Passengers {
+				if !containsString(ids, p.ID) {
+					ids = append(ids, p.ID)
+				}
+			}
+		}
+	}
+	return ids
+}
+
+func countBags(baggages []Baggage) (checked, carryOn int) {
+	for _, b := range baggages {
+		switch b.Type {
+		case BaggageTypeChecked:
+			checked += b.Quantity
+		case BaggageTypeCarryOn:
+			carryOn += b.Quantity
+		}
+	}
+	return checked, carryOn
+}
+
+func overlaps(a, b []string) bool {
+	for _, v := range a {
+		if containsString(b, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,91 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOfferBaggageSummary(t *testing.T) {
+	a := assert.New(t)
+
+	bags := func(checked, carryOn int) []SegmentPassenger {
+		return []SegmentPassenger{
+			{ID: "pas_1", Baggages: []Baggage{{Type: BaggageTypeChecked, Quantity: checked}, {Type: BaggageTypeCarryOn, Quantity: carryOn}}},
+			{ID: "pas_2", Baggages: []Baggage{{Type: BaggageTypeCarryOn, Quantity: 1}}},
+		}
+	}
+
+	offer := &Offer{
+		Passengers: []OfferRequestPassenger{{ID: "pas_1"}, {ID: "pas_2"}},
+		Slices: []Slice{
+			{ID: "sli_1", Segments: []Flight{
+				{ID: "seg_1", Passengers: bags(2, 1)},
+				// A connection on another carrier with a smaller allowance.
+				{ID: "seg_2", Passengers: bags(1, 1)},
+			}},
+			{ID: "sli_2", Segments: []Flight{
+				{ID: "seg_3", Passengers: bags(1, 1)},
+			}},
+		},
+		AvailableServices: []AvailableService{
+			{
+				ID:               "ase_1",
+				Type:             ServiceTypeBaggage,
+				MaximumQuantity:  2,
+				PassengerIDs:     []string{"pas_2"},
+				SegmentIDs:       []string{"seg_1", "seg_2"},
+				RawTotalAmount:   "30.00",
+				RawTotalCurrency: "GBP",
+				Metadata: AvailableServiceMetadata{
+					Type:            BaggageTypeChecked,
+					MaximumWeightKg: 23,
+					MaximumLengthCM: 90,
+					MaximumHeightCM: 75,
+					MaximumDepthCM:  43,
+				},
+			},
+			{
+				ID:              "ase_no_price",
+				Type:            ServiceTypeBaggage,
+				MaximumQuantity: 1,
+				PassengerIDs:    []string{"pas_2"},
+				SegmentIDs:      []string{"seg_1"},
+				Metadata:        AvailableServiceMetadata{Type: BaggageTypeCarryOn},
+			},
+		},
+	}
+
+	summary := offer.BaggageSummary()
+	a.Len(summary, 4)
+
+	outbound := summary[0]
+	a.Equal("pas_1", outbound.PassengerID)
+	a.Equal("sli_1", outbound.SliceID)
+	a.Equal(1, outbound.Checked)
+	a.Equal(1, outbound.CarryOn)
+	a.True(outbound.Varies)
+	a.Len(outbound.Segments, 2)
+	a.Equal(2, outbound.Segments[0].Checked)
+	a.Empty(outbound.Purchasable)
+	a.Equal("1 checked bag and 1 carry-on bag included", outbound.String())
+
+	inbound := summary[1]
+	a.Equal(1, inbound.SliceIndex)
+	a.False(inbound.Varies)
+
+	other := summary[2]
+	a.Equal("pas_2", other.PassengerID)
+	a.Equal(0, other.Checked)
+	a.Equal("1 carry-on bag included", other.String())
+	a.Len(other.Purchasable, 1)
+	a.Equal("ase_1", other.Purchasable[0].ServiceID)
+	a.Equal("30.00", other.Purchasable[0].Price.Number())
+	a.Equal("23kg, 90 x 75 x 43cm", other.Purchasable[0].Dimensions())
+
+	a.Empty(summary[3].Purchasable)
+}
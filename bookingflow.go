@@ -0,0 +1,422 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bojanz/currency"
+)
+
+var (
+	// ErrOfferExpired is returned when the offer being booked has expired.
+	ErrOfferExpired = errors.New("duffel: offer has expired")
+	// ErrOfferPriceChanged is returned when the offer's price has changed since it was
+	// chosen and the booking flow has no OnPriceChange hook to accept the new price.
+	ErrOfferPriceChanged = errors.New("duffel: offer price has changed")
+	// ErrBookingNeedsReview is returned when a booking is resumed after being interrupted
+	// while creating the order or charging the customer, so it isn't known whether that
+	// step took effect.
+	ErrBookingNeedsReview = errors.New("duffel: booking was interrupted and needs review")
+)
+
+type (
+	// BookingClient is the part of the API used by BookingFlow.
+	BookingClient interface {
+		GetOffer(ctx context.Context, id string, params ...GetOfferParams) (*Offer, error)
+		CreateOrder(ctx context.Context, input CreateOrderInput) (*Order, error)
+		CreateOrderCancellation(ctx context.Context, orderID string) (*OrderCancellation, error)
+		ConfirmOrderCancellation(ctx context.Context, orderCancellationID string) (*OrderCancellation, error)
+	}
+
+	// BookingStep is a step of a BookingFlow.
+	BookingStep string
+
+	// BookingStatus is the status of a booking run by a BookingFlow.
+	BookingStatus string
+
+	// BookingHooks plug a BookingFlow into the rest of a checkout. Every hook is optional.
+	BookingHooks struct {
+		// OnPriceChange is called when the refreshed offer's total differs from the
+		// total the booking was started with. Return nil to book at the new price,
+		// which the payment is updated to. Without it, price changes fail with
+		// ErrOfferPriceChanged.
+		OnPriceChange func(ctx context.Context, state *BookingState, previous, current currency.Amount) error
+
+		// Charge takes payment from the customer once the order has been created,
+		// returning a reference to the payment such as a payment intent ID.
+		Charge func(ctx context.Context, state *BookingState) (string, error)
+
+		// Refund gives back a payment taken by Charge when a later step fails.
+		Refund func(ctx context.Context, state *BookingState) error
+
+		// Persist saves the state before creating the order or charging the customer
+		// and after every step, so that an interrupted booking can be resumed by passing
+		// the saved state to Run. Failed saves are retried.
+		//
+		// If the state still can't be saved before the order is created, the booking
+		// fails. Afterwards, Run returns the error and leaves the booking as it is, to
+		// be resumed by passing the same state to Run again, unless
+		// CompensateOnPersistError is set.
+		Persist func(ctx context.Context, state *BookingState) error
+
+		// PersistAttempts is how many times Persist is called before giving up. It defaults to 3.
+		PersistAttempts int
+
+		// CompensateOnPersistError undoes a booking whose state can't be saved once
+		// the order has been created, refunding the customer and cancelling the order.
+		CompensateOnPersistError bool
+
+		// Notify is called once the booking has completed, failed or needs review.
+		Notify func(ctx context.Context, state *BookingState)
+	}
+
+	// BookingFlow books an offer as a series of steps: refresh the offer, create
+	// the order, then charge the customer. If a step fails after the order has
+	// been created, the flow compensates by refunding the customer and cancelling
+	// the order.
+	BookingFlow struct {
+		client BookingClient
+		hooks  BookingHooks
+		now    func() time.Time
+
+		// persistDelay is the wait before retrying Persist, growing with each attempt.
+		persistDelay time.Duration
+	}
+
+	// BookingState is the progress of a booking. It can be saved as JSON and
+	// passed back to BookingFlow.Run to resume an interrupted booking.
+	BookingState struct {
+		Input CreateOrderInput `json:"input"`
+		// OfferTotalAmount is the offer's total amount when the input was built, the
+		// rest of the payment being for services.
+		OfferTotalAmount string        `json:"offer_total_amount,omitempty"`
+		Status           BookingStatus `json:"status"`
+		// Step is the last step that completed.
+		Step BookingStep `json:"step,omitempty"`
+		// Pending is the step that was started but has not completed. It is only set
+		// for steps that create the order or charge the customer.
+		Pending BookingStep `json:"pending,omitempty"`
+
+		OrderID          string `json:"order_id,omitempty"`
+		BookingReference string `json:"booking_reference,omitempty"`
+		PaymentReference string `json:"payment_reference,omitempty"`
+		Refunded         bool   `json:"refunded,omitempty"`
+		CancellationID   string `json:"cancellation_id,omitempty"`
+
+		// Error and ErrorCode describe why the booking failed.
+		Error     string    `json:"error,omitempty"`
+		ErrorCode ErrorCode `json:"error_code,omitempty"`
+		// CompensationError describes why undoing a failed booking failed.
+		CompensationError string `json:"compensation_error,omitempty"`
+
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+)
+
+const (
+	BookingStepRefreshOffer BookingStep = "refresh_offer"
+	BookingStepCreateOrder  BookingStep = "create_order"
+	BookingStepCharge       BookingStep = "charge"
+
+	BookingStatusPending BookingStatus = "pending"
+	// BookingStatusCompensating means a step failed and the flow is undoing the booking.
+	BookingStatusCompensating BookingStatus = "compensating"
+	BookingStatusCompleted    BookingStatus = "completed"
+	// BookingStatusFailed means a step failed and there was nothing to undo, or it was undone.
+	BookingStatusFailed BookingStatus = "failed"
+	// BookingStatusCompensationFailed means a step failed and undoing the booking failed too.
+	// The order and payment need to be checked by hand.
+	BookingStatusCompensationFailed BookingStatus = "compensation_failed"
+	// BookingStatusNeedsReview means the booking was interrupted while creating the order
+	// or charging the customer. The order and payment need to be checked by hand.
+	BookingStatusNeedsReview BookingStatus = "needs_review"
+)
+
+var bookingSteps = []BookingStep{BookingStepRefreshOffer, BookingStepCreateOrder, BookingStepCharge}
+
+// NewBookingFlow returns a flow that books offers with the client.
+func NewBookingFlow(client BookingClient, hooks BookingHooks) *BookingFlow {
+	return &BookingFlow{
+		client:       client,
+		hooks:        hooks,
+		now:          time.Now,
+		persistDelay: 100 * time.Millisecond,
+	}
+}
+
+// NewBookingState returns the state of a new booking of the offer with the order input,
+// such as one built by OrderBuilder.
+func NewBookingState(offer *Offer, input CreateOrderInput) *BookingState {
+	return &BookingState{
+		Input:            input,
+		OfferTotalAmount: offer.RawTotalAmount,
+		Status:           BookingStatusPending,
+	}
+}
+
+// Book books the offer with the order input from the start.
+func (f *BookingFlow) Book(ctx context.Context, offer *Offer, input CreateOrderInput) (*BookingState, error) {
+	state := NewBookingState(offer, input)
+	return state, f.Run(ctx, state)
+}
+
+// Run runs the remaining steps of the booking, resuming after state.Step. Refreshing
+// the offer is run again if it was interrupted, but creating the order and charging
+// the customer aren't, as they may already have taken effect: the booking is moved to
+// BookingStatusNeedsReview and ErrBookingNeedsReview is returned instead. A booking
+// that was interrupted while compensating carries on compensating. It returns the
+// error that failed the booking, such as a *DuffelError from CreateOrder.
+func (f *BookingFlow) Run(ctx context.Context, state *BookingState) error {
+	switch state.Status {
+	case BookingStatusCompleted:
+		return nil
+	case BookingStatusFailed, BookingStatusCompensationFailed, BookingStatusNeedsReview:
+		return fmt.Errorf("duffel: booking has already failed: %s", state.Error)
+	case BookingStatusCompensating:
+		f.compensate(ctx, state)
+		return fmt.Errorf("duffel: booking has already failed: %s", state.Error)
+	case "":
+		state.Status = BookingStatusPending
+	}
+
+	if state.Pending != "" {
+		return f.needsReview(ctx, state)
+	}
+
+	for _, step := range f.remainingSteps(state) {
+		if step != BookingStepRefreshOffer {
+			state.Pending = step
+			if err := f.persist(ctx, state); err != nil {
+				state.Pending = ""
+				return f.persistFailed(ctx, state, err)
+			}
+		}
+		err := f.runStep(ctx, state, step)
+		state.Pending = ""
+		if err != nil {
+			return f.fail(ctx, state, err)
+		}
+		state.Step = step
+		if err := f.persist(ctx, state); err != nil {
+			return f.persistFailed(ctx, state, err)
+		}
+	}
+
+	state.Status = BookingStatusCompleted
+	if err := f.persist(ctx, state); err != nil {
+		state.Status = BookingStatusPending
+		return f.persistFailed(ctx, state, err)
+	}
+	f.notify(ctx, state)
+	return nil
+}
+
+func (f *BookingFlow) remainingSteps(state *BookingState) []BookingStep {
+	for i, step := range bookingSteps {
+		if step == state.Step {
+			return bookingSteps[i+1:]
+		}
+	}
+	return bookingSteps
+}
+
+func (f *BookingFlow) runStep(ctx context.Context, state *BookingState, step BookingStep) error {
+	switch step {
+	case BookingStepRefreshOffer:
+		return f.refreshOffer(ctx, state)
+	case BookingStepCreateOrder:
+		order, err := f.client.CreateOrder(ctx, state.Input)
+		if err != nil {
+			return err
+		}
+		state.OrderID = order.ID
+		state.BookingReference = order.BookingReference
+		return nil
+	case BookingStepCharge:
+		if f.hooks.Charge == nil {
+			return nil
+		}
+		reference, err := f.hooks.Charge(ctx, state)
+		if err != nil {
+			return err
+		}
+		state.PaymentReference = reference
+		return nil
+	}
+	return fmt.Errorf("duffel: unknown booking step %q", step)
+}
+
+// refreshOffer fetches the offer again to check it is still available at the price being paid.
+func (f *BookingFlow) refreshOffer(ctx context.Context, state *BookingState) error {
+	if len(state.Input.SelectedOffers) != 1 {
+		return &DuffelError{Errors: []Error{orderError(InvalidDataParam, "exactly one selected offer is required")}}
+	}
+	offer, err := f.client.GetOffer(ctx, state.Input.SelectedOffers[0])
+	if err != nil {
+		return err
+	}
+	if !offer.ExpiresAt.IsZero() && !offer.ExpiresAt.After(f.now()) {
+		return ErrOfferExpired
+	}
+	if len(state.Input.Payments) == 0 {
+		return nil
+	}
+
+	// The payment covers the offer and its services, so only the offer's share changes.
+	// Without the offer's total, the whole payment is assumed to be for the offer.
+	payment := &state.Input.Payments[0]
+	paying, err := currency.NewAmount(payment.Amount, payment.Currency)
+	if err != nil {
+		return err
+	}
+	current := offer.TotalAmount()
+	if current.CurrencyCode() != paying.CurrencyCode() {
+		return fmt.Errorf("%w: offer is now priced in %s, not %s", ErrOfferPriceChanged, current.CurrencyCode(), paying.CurrencyCode())
+	}
+	previous := paying
+	if state.OfferTotalAmount != "" {
+		previous, err = currency.NewAmount(state.OfferTotalAmount, paying.CurrencyCode())
+		if err != nil {
+			return err
+		}
+	}
+	services, err := paying.Sub(previous)
+	if err != nil {
+		return err
+	}
+	if previous.Equal(current) {
+		return nil
+	}
+
+	if f.hooks.OnPriceChange == nil {
+		return fmt.Errorf("%w from %s to %s", ErrOfferPriceChanged, previous, current)
+	}
+	if err := f.hooks.OnPriceChange(ctx, state, previous, current); err != nil {
+		return err
+	}
+	updated, err := current.Add(services)
+	if err != nil {
+		return err
+	}
+	payment.Amount = updated.Number()
+	state.OfferTotalAmount = current.Number()
+	return nil
+}
+
+// fail records the error and, if the order was created, undoes the booking.
+func (f *BookingFlow) fail(ctx context.Context, state *BookingState, err error) error {
+	state.Error = err.Error()
+	var derr *DuffelError
+	if errors.As(err, &derr) && len(derr.Errors) > 0 {
+		state.ErrorCode = derr.Errors[0].Code
+	}
+
+	if state.OrderID == "" {
+		state.Status = BookingStatusFailed
+		_ = f.persist(ctx, state)
+		f.notify(ctx, state)
+		return err
+	}
+
+	state.Status = BookingStatusCompensating
+	_ = f.persist(ctx, state)
+	f.compensate(ctx, state)
+	return err
+}
+
+// persistFailed handles a state that couldn't be saved. Once the order has been created,
+// a booking is only undone for that if CompensateOnPersistError is set, as the booking
+// itself may be fine.
+func (f *BookingFlow) persistFailed(ctx context.Context, state *BookingState, err error) error {
+	if state.OrderID == "" || f.hooks.CompensateOnPersistError {
+		return f.fail(ctx, state, err)
+	}
+	return fmt.Errorf("duffel: saving booking state: %w", err)
+}
+
+// needsReview stops a booking that was interrupted during a step that may have taken effect.
+func (f *BookingFlow) needsReview(ctx context.Context, state *BookingState) error {
+	state.Status = BookingStatusNeedsReview
+	state.Error = fmt.Sprintf("interrupted during step %s, check the order and payment by hand", state.Pending)
+	_ = f.persist(ctx, state)
+	f.notify(ctx, state)
+	return fmt.Errorf("%w: %s", ErrBookingNeedsReview, state.Error)
+}
+
+// compensate refunds the customer and cancels the order, in the reverse of the order
+// they were taken and created. Each action is recorded so that it isn't repeated if
+// compensation is resumed.
+func (f *BookingFlow) compensate(ctx context.Context, state *BookingState) {
+	err := f.undo(ctx, state)
+	if err != nil {
+		state.Status = BookingStatusCompensationFailed
+		state.CompensationError = err.Error()
+	} else {
+		state.Status = BookingStatusFailed
+		state.CompensationError = ""
+	}
+	_ = f.persist(ctx, state)
+	f.notify(ctx, state)
+}
+
+func (f *BookingFlow) undo(ctx context.Context, state *BookingState) error {
+	if state.PaymentReference != "" && !state.Refunded && f.hooks.Refund != nil {
+		if err := f.hooks.Refund(ctx, state); err != nil {
+			return fmt.Errorf("refunding payment %s: %w", state.PaymentReference, err)
+		}
+		state.Refunded = true
+		_ = f.persist(ctx, state)
+	}
+
+	if state.OrderID == "" {
+		return nil
+	}
+	if state.CancellationID == "" {
+		cancellation, err := f.client.CreateOrderCancellation(ctx, state.OrderID)
+		if err != nil {
+			return fmt.Errorf("cancelling order %s: %w", state.OrderID, err)
+		}
+		state.CancellationID = cancellation.ID
+		_ = f.persist(ctx, state)
+	}
+	if _, err := f.client.ConfirmOrderCancellation(ctx, state.CancellationID); err != nil {
+		return fmt.Errorf("confirming cancellation %s of order %s: %w", state.CancellationID, state.OrderID, err)
+	}
+	return nil
+}
+
+func (f *BookingFlow) persist(ctx context.Context, state *BookingState) error {
+	state.UpdatedAt = f.now()
+	if f.hooks.Persist == nil {
+		return nil
+	}
+
+	attempts := f.hooks.PersistAttempts
+	if attempts < 1 {
+		attempts = 3
+	}
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 && !sleepUntil(ctx, time.Now().Add(time.Duration(i)*f.persistDelay)) {
+			break
+		}
+		if err = f.hooks.Persist(ctx, state); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (f *BookingFlow) notify(ctx context.Context, state *BookingState) {
+	if f.hooks.Notify != nil {
+		f.hooks.Notify(ctx, state)
+	}
+}
+
+var _ BookingClient = (*API)(nil)
@@ -0,0 +1,411 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bojanz/currency"
+	"github.com/segmentio/encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+const testBookingOfferID = "off_00009htYpSCXrwaB9DnUm0"
+
+func testBookingFlow(hooks BookingHooks) *BookingFlow {
+	flow := NewBookingFlow(New("duffel_test_123"), hooks)
+	flow.now = func() time.Time {
+		return time.Date(2020, 1, 17, 10, 0, 0, 0, time.UTC)
+	}
+	flow.persistDelay = 0
+	return flow
+}
+
+func testBookingInput(amount string) (*Offer, CreateOrderInput) {
+	offer := &Offer{ID: testBookingOfferID, RawTotalAmount: "45.00", RawTotalCurrency: "GBP"}
+	return offer, CreateOrderInput{
+		Type:           OrderTypeInstant,
+		SelectedOffers: []string{testBookingOfferID},
+		Passengers:     []OrderPassenger{testOrderPassenger("pas_00009hj8USM7Ncg31cBCL", "Amelia")},
+		Payments:       []PaymentCreateInput{{Amount: amount, Currency: "GBP", Type: PaymentMethodBalance}},
+	}
+}
+
+func mockBookingGetOffer() {
+	gock.New("https://api.duffel.com").
+		Get("/air/offers/"+testBookingOfferID).
+		Reply(200).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/200-offers-off_00009htYpSCXrwaB9DnUm0.json")
+}
+
+func mockBookingCancellation() {
+	gock.New("https://api.duffel.com").
+		Post("/air/order_cancellations").
+		JSON(`{"data":{"order_id":"ord_00009hthhsUZ8W4LxQgkjo"}}`).
+		Reply(201).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/201-create-order-cancellation.json")
+	gock.New("https://api.duffel.com").
+		Post("/air/order_cancellations/ore_00009qzZWzjDipIkqpaUAj/actions/confirm").
+		Reply(201).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/201-create-order-cancellation.json")
+}
+
+func TestBookingFlow(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+
+	mockBookingGetOffer()
+	gock.New("https://api.duffel.com").
+		Post("/air/orders").
+		Reply(201).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/201-create-order.json")
+
+	var persisted []string
+	var notified *BookingState
+	flow := testBookingFlow(BookingHooks{
+		Charge: func(ctx context.Context, state *BookingState) (string, error) {
+			a.Equal("ord_00009hthhsUZ8W4LxQgkjo", state.OrderID)
+			return "pi_123", nil
+		},
+		Persist: func(ctx context.Context, state *BookingState) error {
+			persisted = append(persisted, fmt.Sprintf("%s %s %s", state.Status, state.Step, state.Pending))
+			return nil
+		},
+		Notify: func(ctx context.Context, state *BookingState) {
+			notified = state
+		},
+	})
+
+	offer, input := testBookingInput("45.00")
+	state, err := flow.Book(context.TODO(), offer, input)
+	require.NoError(t, err)
+	a.Equal(BookingStatusCompleted, state.Status)
+	a.Equal(BookingStepCharge, state.Step)
+	a.Equal("RZPNX8", state.BookingReference)
+	a.Equal("pi_123", state.PaymentReference)
+	a.Equal([]string{
+		"pending refresh_offer ",
+		"pending refresh_offer create_order",
+		"pending create_order ",
+		"pending create_order charge",
+		"pending charge ",
+		"completed charge ",
+	}, persisted)
+	a.Same(state, notified)
+	a.True(gock.IsDone())
+}
+
+func TestBookingFlowPriceChange(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+
+	// The offer now costs 45.00, but was 40.00 when the 10.00 of services were added.
+	mockBookingGetOffer()
+	offer, input := testBookingInput("50.00")
+	offer.RawTotalAmount = "40.00"
+
+	state, err := testBookingFlow(BookingHooks{}).Book(context.TODO(), offer, input)
+	a.True(errors.Is(err, ErrOfferPriceChanged))
+	a.EqualError(err, "duffel: offer price has changed from 40.00 GBP to 45.00 GBP")
+	a.Equal(BookingStatusFailed, state.Status)
+	a.Empty(state.OrderID)
+
+	mockBookingGetOffer()
+	gock.New("https://api.duffel.com").
+		Post("/air/orders").
+		JSON(`{"data":{"type":"instant","passengers":[{"id":"pas_00009hj8USM7Ncg31cBCL","title":"ms","family_name":"Earhart","given_name":"Amelia","born_on":"1987-07-24","email":"amelia@example.com","gender":"f","phone_number":"+442080160509","type":""}],"payments":[{"amount":"55.00","currency":"GBP","type":"balance"}],"selected_offers":["off_00009htYpSCXrwaB9DnUm0"]}}`).
+		Reply(201).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/201-create-order.json")
+
+	var previous, current currency.Amount
+	state, err = testBookingFlow(BookingHooks{
+		OnPriceChange: func(ctx context.Context, state *BookingState, p, c currency.Amount) error {
+			previous, current = p, c
+			return nil
+		},
+	}).Book(context.TODO(), offer, input)
+	require.NoError(t, err)
+	a.Equal("40.00 GBP", previous.String())
+	a.Equal("45.00 GBP", current.String())
+	a.Equal("55.00", state.Input.Payments[0].Amount)
+	a.Equal("45.00", state.OfferTotalAmount)
+	a.True(gock.IsDone())
+}
+
+func TestBookingFlowCompensation(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+
+	mockBookingGetOffer()
+	gock.New("https://api.duffel.com").
+		Post("/air/orders").
+		Reply(201).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/201-create-order.json")
+	mockBookingCancellation()
+
+	declined := errors.New("card declined")
+	flow := testBookingFlow(BookingHooks{
+		Charge: func(ctx context.Context, state *BookingState) (string, error) {
+			return "", declined
+		},
+		Refund: func(ctx context.Context, state *BookingState) error {
+			t.Fatal("nothing was charged, so nothing should be refunded")
+			return nil
+		},
+	})
+
+	offer, input := testBookingInput("45.00")
+	state, err := flow.Book(context.TODO(), offer, input)
+	a.Equal(declined, err)
+	a.Equal(BookingStatusFailed, state.Status)
+	a.Equal(BookingStepCreateOrder, state.Step)
+	a.Equal("card declined", state.Error)
+	a.Equal("ore_00009qzZWzjDipIkqpaUAj", state.CancellationID)
+	a.Empty(state.CompensationError)
+	a.True(gock.IsDone())
+}
+
+func TestBookingFlowCreateOrderError(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+
+	mockBookingGetOffer()
+	gock.New("https://api.duffel.com").
+		Post("/air/orders").
+		Reply(422).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		JSON(`{"meta":{"status":422,"request_id":"FZW0H3HdJwKk5HMAAKxB"},"errors":[{"type":"validation_error","title":"Insufficient balance","message":"There wasn't enough balance in the wallet","code":"insufficient_balance"}]}`)
+
+	charged := false
+	flow := testBookingFlow(BookingHooks{
+		Charge: func(ctx context.Context, state *BookingState) (string, error) {
+			charged = true
+			return "", nil
+		},
+	})
+
+	offer, input := testBookingInput("45.00")
+	state, err := flow.Book(context.TODO(), offer, input)
+	a.True(IsErrorCode(err, InsufficientBalance))
+	a.Equal(BookingStatusFailed, state.Status)
+	a.Equal(InsufficientBalance, state.ErrorCode)
+	a.False(charged)
+	a.True(gock.IsDone())
+}
+
+func TestBookingFlowResume(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+
+	// The process stopped after the order was created and the state was saved.
+	offer, input := testBookingInput("45.00")
+	saved := NewBookingState(offer, input)
+	saved.Step = BookingStepCreateOrder
+	saved.OrderID = "ord_00009hthhsUZ8W4LxQgkjo"
+	data, err := json.Marshal(saved)
+	require.NoError(t, err)
+
+	var state BookingState
+	require.NoError(t, json.Unmarshal(data, &state))
+	a.Equal("1987-07-24", state.Input.Passengers[0].BornOn.String())
+
+	// Saving fails twice before it succeeds, which is retried rather than failing the booking.
+	charges, failures := 0, 2
+	flow := testBookingFlow(BookingHooks{
+		Charge: func(ctx context.Context, state *BookingState) (string, error) {
+			charges++
+			return "pi_123", nil
+		},
+		Persist: func(ctx context.Context, state *BookingState) error {
+			if failures > 0 {
+				failures--
+				return errors.New("database unavailable")
+			}
+			return nil
+		},
+	})
+	require.NoError(t, flow.Run(context.TODO(), &state))
+	a.Equal(BookingStatusCompleted, state.Status)
+	a.Equal("pi_123", state.PaymentReference)
+	a.Equal(1, charges)
+
+	// Running a completed booking again does nothing.
+	require.NoError(t, flow.Run(context.TODO(), &state))
+	a.Equal(1, charges)
+}
+
+func TestBookingFlowPersistError(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+
+	offer, input := testBookingInput("45.00")
+	state := NewBookingState(offer, input)
+	state.Step = BookingStepCreateOrder
+	state.OrderID = "ord_00009hthhsUZ8W4LxQgkjo"
+
+	// A paid booking whose state can't be saved is left as it is, not undone.
+	persistErr := errors.New("database unavailable")
+	refunded := false
+	hooks := BookingHooks{
+		Charge: func(ctx context.Context, state *BookingState) (string, error) {
+			return "pi_123", nil
+		},
+		Persist: func(ctx context.Context, state *BookingState) error {
+			if state.Step == BookingStepCharge {
+				return persistErr
+			}
+			return nil
+		},
+		Refund: func(ctx context.Context, state *BookingState) error {
+			refunded = true
+			return nil
+		},
+	}
+	err := testBookingFlow(hooks).Run(context.TODO(), state)
+	a.True(errors.Is(err, persistErr))
+	a.Equal(BookingStatusPending, state.Status)
+	a.Equal(BookingStepCharge, state.Step)
+	a.False(refunded)
+	a.Empty(state.CancellationID)
+
+	// Once saving works again, running the same state completes the booking without charging again.
+	hooks.Persist = nil
+	hooks.Charge = nil
+	require.NoError(t, testBookingFlow(hooks).Run(context.TODO(), state))
+	a.Equal(BookingStatusCompleted, state.Status)
+
+	// Undoing the booking on persist errors is opt-in.
+	state = NewBookingState(offer, input)
+	state.Step = BookingStepCreateOrder
+	state.OrderID = "ord_00009hthhsUZ8W4LxQgkjo"
+	hooks.Charge = func(ctx context.Context, state *BookingState) (string, error) {
+		return "pi_123", nil
+	}
+	hooks.Persist = func(ctx context.Context, state *BookingState) error {
+		if state.PaymentReference != "" {
+			return persistErr
+		}
+		return nil
+	}
+	hooks.CompensateOnPersistError = true
+	mockBookingCancellation()
+	err = testBookingFlow(hooks).Run(context.TODO(), state)
+	a.True(errors.Is(err, persistErr))
+	a.Equal(BookingStatusFailed, state.Status)
+	a.True(refunded)
+	a.True(gock.IsDone())
+
+	// Running a failed booking again does nothing.
+	a.Error(testBookingFlow(hooks).Run(context.TODO(), state))
+
+	// A booking that was interrupted while compensating carries on.
+	state.Status = BookingStatusCompensating
+	gock.New("https://api.duffel.com").
+		Post("/air/order_cancellations/ore_00009qzZWzjDipIkqpaUAj/actions/confirm").
+		Reply(500).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		JSON(`{"errors":[{"type":"api_error","title":"Internal error","message":"There was something wrong on our end","code":"internal_server_error"}]}`)
+	a.Error(testBookingFlow(BookingHooks{}).Run(context.TODO(), state))
+	a.Equal(BookingStatusCompensationFailed, state.Status)
+	a.Contains(state.CompensationError, "confirming cancellation ore_00009qzZWzjDipIkqpaUAj of order ord_00009hthhsUZ8W4LxQgkjo")
+}
+
+func TestBookingFlowInterrupted(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+
+	mockBookingGetOffer()
+	gock.New("https://api.duffel.com").
+		Post("/air/orders").
+		Reply(201).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/201-create-order.json")
+
+	// The process crashes once the order has been created, before its state is saved.
+	errCrashed := errors.New("crashed")
+	var saved []byte
+	charged := false
+	hooks := BookingHooks{
+		Charge: func(ctx context.Context, state *BookingState) (string, error) {
+			charged = true
+			return "pi_123", nil
+		},
+		Persist: func(ctx context.Context, state *BookingState) error {
+			if state.OrderID != "" {
+				return errCrashed
+			}
+			data, err := json.Marshal(state)
+			saved = data
+			return err
+		},
+	}
+	offer, input := testBookingInput("45.00")
+	_, err := testBookingFlow(hooks).Book(context.TODO(), offer, input)
+	a.True(errors.Is(err, errCrashed))
+	a.True(gock.IsDone())
+
+	// Resuming from the saved state doesn't create a second order or charge the customer.
+	var state BookingState
+	require.NoError(t, json.Unmarshal(saved, &state))
+	a.Equal(BookingStepCreateOrder, state.Pending)
+
+	var notified *BookingState
+	hooks.Persist = nil
+	hooks.Notify = func(ctx context.Context, state *BookingState) {
+		notified = state
+	}
+	err = testBookingFlow(hooks).Run(context.TODO(), &state)
+	a.True(errors.Is(err, ErrBookingNeedsReview))
+	a.Equal(BookingStatusNeedsReview, state.Status)
+	a.Equal("interrupted during step create_order, check the order and payment by hand", state.Error)
+	a.Same(&state, notified)
+	a.False(charged)
+	a.True(gock.IsDone())
+
+	// The same goes for a crash while charging.
+	state = BookingState{Status: BookingStatusPending, Step: BookingStepCreateOrder, Pending: BookingStepCharge, OrderID: "ord_00009hthhsUZ8W4LxQgkjo"}
+	a.True(errors.Is(testBookingFlow(hooks).Run(context.TODO(), &state), ErrBookingNeedsReview))
+	a.False(charged)
+	a.Error(testBookingFlow(hooks).Run(context.TODO(), &state))
+}
@@ -0,0 +1,287 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+type (
+	// CityResolverClient is the set of clients a CityResolver loads its data from.
+	CityResolverClient interface {
+		AirportsClient
+		PlacesClient
+	}
+
+	// CityResolver expands city codes such as "LON" into the airports that serve
+	// the city, and collapses airport codes back into their city, so that a search
+	// for "London" covers LHR, LGW, STN, LTN, LCY and SEN and the offers returned
+	// can be grouped by the cities travelled between.
+	CityResolver struct {
+		cities         map[string]*City
+		citiesByName   map[string][]*City
+		airports       map[string]*Airport
+		airportsByCity map[string][]*Airport
+		cityByAirport  map[string]string
+	}
+
+	// CityPair is the origin and destination of a slice, as city codes.
+	// Airports that don't belong to a city are represented by their own code.
+	CityPair struct {
+		Origin      string
+		Destination string
+	}
+)
+
+// NewCityResolver loads all cities and airports from the client and returns a
+// resolver backed by them. Cities referenced by airports but missing from the
+// city list are fetched individually.
+func NewCityResolver(ctx context.Context, client CityResolverClient) (*CityResolver, error) {
+	cities, err := Collect(client.Cities(ctx, ListCitiesParams{ListOptions: ListOptions{Limit: maxListLimit}}))
+	if err != nil {
+		return nil, err
+	}
+
+	airports, err := Collect(client.ListAirports(ctx, ListAirportsParams{ListOptions: ListOptions{Limit: maxListLimit}}))
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(cities))
+	for _, city := range cities {
+		known[city.ID] = true
+	}
+	for _, airport := range airports {
+		if airport.City.IATACode != "" || airport.City.ID == "" || known[airport.City.ID] {
+			continue
+		}
+		city, err := client.City(ctx, airport.City.ID)
+		if err != nil {
+			return nil, err
+		}
+		cities = append(cities, city)
+		known[city.ID] = true
+	}
+
+	return NewCityResolverFromList(cities, airports), nil
+}
+
+// NewCityResolverFromList returns a resolver backed by already loaded cities and
+// airports, such as those held by a ReferenceCache:
+//
+//	snapshot := cache.Snapshot()
+//	resolver := duffel.NewCityResolverFromList(snapshot.Cities, snapshot.Airports)
+func NewCityResolverFromList(cities []*City, airports []*Airport) *CityResolver {
+	r := &CityResolver{
+		cities:         make(map[string]*City, len(cities)),
+		citiesByName:   make(map[string][]*City, len(cities)),
+		airports:       make(map[string]*Airport, len(airports)),
+		airportsByCity: make(map[string][]*Airport),
+		cityByAirport:  make(map[string]string, len(airports)),
+	}
+
+	byID := make(map[string]*City, len(cities))
+	for _, city := range cities {
+		if city == nil {
+			continue
+		}
+		byID[city.ID] = city
+		if city.IATACode != "" {
+			indexCode(r.cities, city.IATACode, city)
+		}
+		name := strings.ToLower(city.Name)
+		r.citiesByName[name] = append(r.citiesByName[name], city)
+	}
+
+	for _, airport := range airports {
+		if airport == nil || airport.IATACode == "" {
+			continue
+		}
+		indexCode(r.airports, airport.IATACode, airport)
+
+		cityCode := airport.City.IATACode
+		if city, ok := byID[airport.City.ID]; ok && cityCode == "" {
+			cityCode = city.IATACode
+		}
+		if cityCode != "" {
+			cityCode = strings.ToUpper(cityCode)
+			r.airportsByCity[cityCode] = append(r.airportsByCity[cityCode], airport)
+			r.cityByAirport[strings.ToUpper(airport.IATACode)] = cityCode
+		}
+	}
+
+	for _, airports := range r.airportsByCity {
+		sort.Slice(airports, func(i, j int) bool {
+			return airports[i].IATACode < airports[j].IATACode
+		})
+	}
+
+	return r
+}
+
+// City returns the city with the given IATA code, e.g. "LON", or name, e.g. "London".
+// When several cities share a name, the one served by the most airports is returned.
+func (r *CityResolver) City(query string) (*City, bool) {
+	if city, ok := r.cities[strings.ToUpper(query)]; ok {
+		return city, true
+	}
+
+	var best *City
+	for _, city := range r.citiesByName[strings.ToLower(strings.TrimSpace(query))] {
+		if best == nil || len(r.airportsByCity[strings.ToUpper(city.IATACode)]) > len(r.airportsByCity[strings.ToUpper(best.IATACode)]) {
+			best = city
+		}
+	}
+	return best, best != nil
+}
+
+// Airports returns the airports serving a place, ordered by IATA code.
+// The place may be a city code, a city name or an airport code,
+// in which case only that airport is returned.
+func (r *CityResolver) Airports(place string) []*Airport {
+	if city, ok := r.City(place); ok {
+		if airports := r.airportsByCity[strings.ToUpper(city.IATACode)]; len(airports) > 0 {
+			return airports
+		}
+	}
+	if airport, ok := r.airports[strings.ToUpper(place)]; ok {
+		return []*Airport{airport}
+	}
+	return nil
+}
+
+// AirportCodes returns the IATA codes of the airports serving a place.
+// Unknown places are returned unchanged so that they can still be searched for.
+func (r *CityResolver) AirportCodes(place string) []string {
+	airports := r.Airports(place)
+	if len(airports) == 0 {
+		return []string{place}
+	}
+
+	codes := make([]string, len(airports))
+	for i, airport := range airports {
+		codes[i] = airport.IATACode
+	}
+	return codes
+}
+
+// CityCode returns the IATA code of the city an airport belongs to. City codes,
+// airports without a city and unknown codes are returned unchanged.
+func (r *CityResolver) CityCode(code string) string {
+	code = strings.ToUpper(code)
+	if _, ok := r.cities[code]; ok {
+		return code
+	}
+	if cityCode, ok := r.cityByAirport[code]; ok {
+		return cityCode
+	}
+	return code
+}
+
+// ExpandSlice returns one slice for every combination of the airports serving
+// the slice's origin and destination. Combinations where both ends are the
+// same airport are left out.
+func (r *CityResolver) ExpandSlice(slice OfferRequestSlice) []OfferRequestSlice {
+	origins := r.AirportCodes(slice.Origin)
+	destinations := r.AirportCodes(slice.Destination)
+
+	slices := make([]OfferRequestSlice, 0, len(origins)*len(destinations))
+	for _, origin := range origins {
+		for _, destination := range destinations {
+			if origin == destination {
+				continue
+			}
+			slices = append(slices, OfferRequestSlice{
+				DepartureDate: slice.DepartureDate,
+				Origin:        origin,
+				Destination:   destination,
+			})
+		}
+	}
+	return slices
+}
+
+// ExpandOfferRequest returns one offer request for every combination of the
+// airports serving the origins and destinations of the request's slices.
+//
+// The number of requests grows quickly with the number of slices, so this is
+// best suited to one-way and return journeys.
+func (r *CityResolver) ExpandOfferRequest(input OfferRequestInput) []OfferRequestInput {
+	combinations := [][]OfferRequestSlice{{}}
+	for _, slice := range input.Slices {
+		expanded := r.ExpandSlice(slice)
+		next := make([][]OfferRequestSlice, 0, len(combinations)*len(expanded))
+		for _, combination := range combinations {
+			for _, s := range expanded {
+				slices := make([]OfferRequestSlice, len(combination), len(combination)+1)
+				copy(slices, combination)
+				next = append(next, append(slices, s))
+			}
+		}
+		combinations = next
+	}
+
+	requests := make([]OfferRequestInput, len(combinations))
+	for i, slices := range combinations {
+		requests[i] = input
+		requests[i].Slices = slices
+	}
+	return requests
+}
+
+// NormalizeSlice returns the slice with its origin and destination replaced by the city codes they belong to.
+func (r *CityResolver) NormalizeSlice(slice OfferRequestSlice) OfferRequestSlice {
+	slice.Origin = r.CityCode(slice.Origin)
+	slice.Destination = r.CityCode(slice.Destination)
+	return slice
+}
+
+// CityPairs returns the city pair travelled between on each slice of the offer.
+func (r *CityResolver) CityPairs(offer *Offer) []CityPair {
+	pairs := make([]CityPair, 0, len(offer.Slices))
+	for _, slice := range offer.Slices {
+		if slice.BaseSlice == nil {
+			continue
+		}
+		pairs = append(pairs, CityPair{
+			Origin:      r.locationCityCode(slice.Origin),
+			Destination: r.locationCityCode(slice.Destination),
+		})
+	}
+	return pairs
+}
+
+// GroupByCityPairs groups offers by the city pairs of their slices, so that
+// offers from an expanded search can be compared per journey. Groups are keyed
+// by the city pairs joined with a comma, e.g. "LON-NYC,NYC-LON".
+func (r *CityResolver) GroupByCityPairs(offers []*Offer) map[string][]*Offer {
+	groups := make(map[string][]*Offer)
+	for _, offer := range offers {
+		pairs := r.CityPairs(offer)
+		keys := make([]string, len(pairs))
+		for i, pair := range pairs {
+			keys[i] = pair.String()
+		}
+		key := strings.Join(keys, ",")
+		groups[key] = append(groups[key], offer)
+	}
+	return groups
+}
+
+func (r *CityResolver) locationCityCode(loc Location) string {
+	if loc.IATACityCode != nil && *loc.IATACityCode != "" {
+		return strings.ToUpper(*loc.IATACityCode)
+	}
+	if loc.City != nil && loc.City.IATACode != "" {
+		return strings.ToUpper(loc.City.IATACode)
+	}
+	return r.CityCode(loc.IATACode)
+}
+
+func (p CityPair) String() string {
+	return p.Origin + "-" + p.Destination
+}
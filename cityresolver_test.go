@@ -0,0 +1,133 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func testCityResolver() *CityResolver {
+	london := &City{ID: "cit_lon_gb", Name: "London", IATACode: "LON"}
+	londonOntario := &City{ID: "cit_yxu_ca", Name: "London", IATACode: "YXU"}
+	newYork := &City{ID: "cit_nyc_us", Name: "New York", IATACode: "NYC"}
+
+	airports := []*Airport{{IATACode: "YXU", City: *londonOntario}}
+	for _, code := range []string{"LHR", "LGW", "STN", "LTN", "LCY", "SEN"} {
+		airports = append(airports, &Airport{IATACode: code, City: *london})
+	}
+	for _, code := range []string{"JFK", "LGA", "EWR"} {
+		airports = append(airports, &Airport{IATACode: code, City: City{ID: newYork.ID}})
+	}
+	airports = append(airports, &Airport{IATACode: "BFS"})
+
+	return NewCityResolverFromList([]*City{london, londonOntario, newYork}, airports)
+}
+
+func TestCityResolverAirports(t *testing.T) {
+	a := assert.New(t)
+	r := testCityResolver()
+
+	a.Equal([]string{"LCY", "LGW", "LHR", "LTN", "SEN", "STN"}, r.AirportCodes("London"))
+	a.Equal([]string{"LCY", "LGW", "LHR", "LTN", "SEN", "STN"}, r.AirportCodes("lon"))
+	a.Equal([]string{"EWR", "JFK", "LGA"}, r.AirportCodes("NYC"))
+	a.Equal([]string{"JFK"}, r.AirportCodes("JFK"))
+	a.Equal([]string{"XXX"}, r.AirportCodes("XXX"))
+
+	a.Equal("LON", r.CityCode("lhr"))
+	a.Equal("NYC", r.CityCode("JFK"))
+	a.Equal("LON", r.CityCode("LON"))
+	a.Equal("BFS", r.CityCode("BFS"))
+}
+
+func TestCityResolverExpandOfferRequest(t *testing.T) {
+	a := assert.New(t)
+	r := testCityResolver()
+
+	slices := r.ExpandSlice(OfferRequestSlice{Origin: "LON", Destination: "JFK"})
+	a.Len(slices, 6)
+	a.Equal("LCY", slices[0].Origin)
+	a.Equal("JFK", slices[0].Destination)
+
+	requests := r.ExpandOfferRequest(OfferRequestInput{
+		CabinClass: CabinClassEconomy,
+		Slices: []OfferRequestSlice{
+			{Origin: "LON", Destination: "NYC"},
+			{Origin: "NYC", Destination: "LHR"},
+		},
+	})
+	a.Len(requests, 18*3)
+	a.Equal(CabinClassEconomy, requests[0].CabinClass)
+	a.Len(requests[0].Slices, 2)
+
+	a.Equal(OfferRequestSlice{Origin: "LON", Destination: "NYC"}, r.NormalizeSlice(OfferRequestSlice{Origin: "LGW", Destination: "EWR"}))
+}
+
+func TestCityResolverGroupByCityPairs(t *testing.T) {
+	a := assert.New(t)
+	r := testCityResolver()
+
+	offer := func(id, origin, destination string) *Offer {
+		return &Offer{ID: id, Slices: []Slice{{BaseSlice: &BaseSlice{
+			Origin:      Location{IATACode: origin},
+			Destination: Location{IATACode: destination},
+		}}}}
+	}
+
+	groups := r.GroupByCityPairs([]*Offer{
+		offer("off_1", "LHR", "JFK"),
+		offer("off_2", "LGW", "EWR"),
+		offer("off_3", "BFS", "JFK"),
+	})
+	a.Len(groups, 2)
+	a.Len(groups["LON-NYC"], 2)
+	a.Len(groups["BFS-NYC"], 1)
+}
+
+func TestNewCityResolver(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+
+	gock.New("https://api.duffel.com").
+		Get("/air/cities").
+		Reply(200).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/200-list-cities.json")
+
+	gock.New("https://api.duffel.com").
+		Get("/air/airports").
+		MatchParam("after", "g2wAAAACbQAAABBBZXJvbWlzdC1LaGFya2l2bQAAAB=").
+		Reply(200).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/200-list-empty.json")
+
+	gock.New("https://api.duffel.com").
+		Get("/air/airports").
+		Reply(200).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/200-list-airports.json")
+
+	r, err := NewCityResolver(context.TODO(), New("duffel_test_123"))
+	a.NoError(err)
+	a.True(gock.IsDone())
+
+	city, ok := r.City("London")
+	a.True(ok)
+	a.Equal("LON", city.IATACode)
+	a.Equal([]string{"LHR"}, r.AirportCodes("LON"))
+}
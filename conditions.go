@@ -0,0 +1,241 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bojanz/currency"
+)
+
+type (
+	// Permission is whether the fare conditions allow a modification.
+	Permission string
+
+	// ConditionResult is the answer to whether a modification is allowed, and at what cost.
+	ConditionResult struct {
+		Permission Permission
+		// Penalty is the penalty charged by the airline. It is only set if PenaltyKnown is true.
+		Penalty currency.Amount
+		// PenaltyKnown is false if the modification is allowed but the airline
+		// didn't say what it would cost.
+		PenaltyKnown bool
+		// RefundAmount is what would be refunded, which is the total amount less the
+		// penalty. It is only set by Refund, if RefundAmountKnown is true.
+		RefundAmount currency.Amount
+		// RefundAmountKnown is false if the penalty is unknown or in a different
+		// currency from the total amount.
+		RefundAmountKnown bool
+		// Reason explains how the result was reached.
+		Reason string
+	}
+
+	// ConditionsEvaluator answers questions about the refund and change conditions
+	// of an offer or order, combining the conditions that apply to the whole
+	// offer with those of each slice.
+	//
+	// Conditions the airline didn't provide are reported as PermissionUnknown
+	// rather than as not allowed.
+	ConditionsEvaluator struct {
+		conditions Conditions
+		slices     []Slice
+		total      currency.Amount
+		order      bool
+		cancelled  bool
+		at         time.Time
+	}
+)
+
+const (
+	PermissionAllowed    Permission = "allowed"
+	PermissionNotAllowed Permission = "not_allowed"
+	PermissionUnknown    Permission = "unknown"
+)
+
+// EvaluateOfferConditions returns an evaluator for the offer's conditions.
+func EvaluateOfferConditions(offer *Offer) *ConditionsEvaluator {
+	return &ConditionsEvaluator{
+		conditions: offer.Conditions,
+		slices:     offer.Slices,
+		total:      offer.TotalAmount(),
+	}
+}
+
+// EvaluateOrderConditions returns an evaluator for the order's conditions.
+// Cancelled orders can't be refunded or changed.
+func EvaluateOrderConditions(order *Order) *ConditionsEvaluator {
+	return &ConditionsEvaluator{
+		conditions: order.Conditions,
+		slices:     order.Slices,
+		total:      order.TotalAmount(),
+		order:      true,
+		cancelled:  order.CancelledAt != nil,
+	}
+}
+
+// At returns an evaluator that also takes into account whether the flights
+// have departed by the given time, e.g. time.Now() to ask whether an order
+// can be refunded right now.
+func (e *ConditionsEvaluator) At(t time.Time) *ConditionsEvaluator {
+	at := *e
+	at.at = t
+	return &at
+}
+
+// Refund returns whether the whole offer or order can be refunded before departure,
+// and how much would be refunded.
+func (e *ConditionsEvaluator) Refund() ConditionResult {
+	if e.cancelled {
+		return notAllowed("the order is cancelled")
+	}
+
+	result := evaluateCondition(e.conditions.RefundBeforeDeparture, "refunds")
+	if len(e.slices) > 0 {
+		result = e.checkDeparture(result, &e.slices[0])
+	}
+	if !result.Allowed() || !result.PenaltyKnown || e.total.CurrencyCode() != result.Penalty.CurrencyCode() {
+		return result
+	}
+
+	refund, err := e.total.Sub(result.Penalty)
+	if err != nil {
+		return result
+	}
+	if refund.IsNegative() {
+		// The penalty can't be more than what was paid.
+		refund, _ = currency.NewAmount("0", refund.CurrencyCode())
+	}
+	result.RefundAmount, result.RefundAmountKnown = refund, true
+	return result
+}
+
+// ChangeSlice returns whether the slice at index i can be changed before departure.
+// Conditions given for the slice take precedence over those for the whole offer.
+func (e *ConditionsEvaluator) ChangeSlice(i int) ConditionResult {
+	if i < 0 || i >= len(e.slices) {
+		return ConditionResult{Permission: PermissionUnknown, Reason: fmt.Sprintf("there is no slice %d", i)}
+	}
+	if e.cancelled {
+		return notAllowed("the order is cancelled")
+	}
+
+	slice := &e.slices[i]
+	if e.order && !slice.Changeable {
+		return notAllowed("the slice is not changeable")
+	}
+
+	var result ConditionResult
+	if slice.Conditions.ChangeBeforeDeparture != nil {
+		result = evaluateCondition(slice.Conditions.ChangeBeforeDeparture, "changes to this slice")
+	} else {
+		result = evaluateCondition(e.conditions.ChangeBeforeDeparture, "changes")
+	}
+	return e.checkDeparture(result, slice)
+}
+
+// Change returns whether every slice can be changed, along with the worst-case
+// penalty of changing any one of them. If any slice can't be changed the result
+// is not allowed; otherwise it is unknown if any slice is unknown.
+func (e *ConditionsEvaluator) Change() ConditionResult {
+	if len(e.slices) == 0 {
+		return evaluateCondition(e.conditions.ChangeBeforeDeparture, "changes")
+	}
+
+	worst := ConditionResult{Permission: PermissionAllowed, PenaltyKnown: true}
+	mixedCurrencies := false
+	for i := range e.slices {
+		result := e.ChangeSlice(i)
+		switch {
+		case result.Permission == PermissionNotAllowed:
+			return result
+		case result.Permission == PermissionUnknown:
+			worst.Permission = PermissionUnknown
+			worst.Reason = result.Reason
+		case !result.PenaltyKnown:
+			worst.PenaltyKnown = false
+		case worst.Penalty.CurrencyCode() == "":
+			worst.Penalty = result.Penalty
+		default:
+			cmp, err := result.Penalty.Cmp(worst.Penalty)
+			if err != nil {
+				// Penalties in different currencies can't be compared.
+				worst.PenaltyKnown = false
+				mixedCurrencies = true
+			} else if cmp > 0 {
+				worst.Penalty = result.Penalty
+			}
+		}
+	}
+
+	switch {
+	case worst.Permission == PermissionUnknown:
+		worst.Penalty, worst.PenaltyKnown = currency.Amount{}, false
+	case mixedCurrencies:
+		worst.Penalty = currency.Amount{}
+		worst.Reason = "changes are allowed, but the slices' penalties are in different currencies"
+	case !worst.PenaltyKnown:
+		worst.Penalty = currency.Amount{}
+		worst.Reason = "changes are allowed, but the penalty for at least one slice is unknown"
+	default:
+		worst.Reason = fmt.Sprintf("changes are allowed with a worst-case penalty of %s", worst.Penalty)
+	}
+	return worst
+}
+
+// Allowed reports whether the modification is known to be allowed.
+func (r ConditionResult) Allowed() bool {
+	return r.Permission == PermissionAllowed
+}
+
+// Free reports whether the modification is known to be allowed without a penalty.
+func (r ConditionResult) Free() bool {
+	return r.Allowed() && r.PenaltyKnown && r.Penalty.IsZero()
+}
+
+// checkDeparture marks an allowed result as not allowed once the slice has departed.
+func (e *ConditionsEvaluator) checkDeparture(result ConditionResult, slice *Slice) ConditionResult {
+	if e.at.IsZero() || result.Permission != PermissionAllowed || len(slice.Segments) == 0 {
+		return result
+	}
+
+	departingAt, err := slice.Segments[0].DepartingAt()
+	if err != nil {
+		return ConditionResult{Permission: PermissionUnknown, Reason: "the departure time is unknown"}
+	}
+	if !e.at.Before(departingAt) {
+		return notAllowed("the flight has departed")
+	}
+	return result
+}
+
+func evaluateCondition(c *ChangeCondition, name string) ConditionResult {
+	switch {
+	case c == nil:
+		return ConditionResult{Permission: PermissionUnknown, Reason: "the airline did not provide conditions for " + name}
+	case !c.Allowed:
+		return notAllowed(name + " are not allowed")
+	case c.RawPenaltyAmount == nil || c.RawPenaltyCurrency == nil:
+		return ConditionResult{Permission: PermissionAllowed, Reason: name + " are allowed with an unknown penalty"}
+	}
+
+	penalty, err := currency.NewAmount(*c.RawPenaltyAmount, *c.RawPenaltyCurrency)
+	if err != nil {
+		return ConditionResult{Permission: PermissionAllowed, Reason: name + " are allowed with an unknown penalty"}
+	}
+	if penalty.IsZero() {
+		return ConditionResult{Permission: PermissionAllowed, Penalty: penalty, PenaltyKnown: true, Reason: name + " are allowed free of charge"}
+	}
+	return ConditionResult{
+		Permission:   PermissionAllowed,
+		Penalty:      penalty,
+		PenaltyKnown: true,
+		Reason:       fmt.Sprintf("%s are allowed with a penalty of %s", name, penalty),
+	}
+}
+
+func notAllowed(reason string) ConditionResult {
+	return ConditionResult{Permission: PermissionNotAllowed, Reason: reason}
+}
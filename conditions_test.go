@@ -0,0 +1,165 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func penaltyCondition(amount string) *ChangeCondition {
+	currency := "GBP"
+	return &ChangeCondition{Allowed: true, RawPenaltyAmount: &amount, RawPenaltyCurrency: &currency}
+}
+
+func testConditionsSlice(departing string, conditions SliceConditions) Slice {
+	return Slice{
+		Changeable: true,
+		Conditions: conditions,
+		Segments: []Flight{{
+			Origin:         Location{IATACode: "LHR", TimeZone: "Europe/London"},
+			RawDepartingAt: departing,
+		}},
+	}
+}
+
+func TestEvaluateOfferConditions(t *testing.T) {
+	a := assert.New(t)
+
+	offer := &Offer{
+		Conditions: Conditions{
+			RefundBeforeDeparture: &ChangeCondition{Allowed: false},
+			ChangeBeforeDeparture: penaltyCondition("50.00"),
+		},
+		Slices: []Slice{
+			testConditionsSlice("2024-03-10T18:00:00", SliceConditions{}),
+			testConditionsSlice("2024-03-17T18:00:00", SliceConditions{ChangeBeforeDeparture: penaltyCondition("75.00")}),
+		},
+	}
+
+	e := EvaluateOfferConditions(offer)
+
+	refund := e.Refund()
+	a.Equal(PermissionNotAllowed, refund.Permission)
+	a.Equal("refunds are not allowed", refund.Reason)
+
+	change := e.ChangeSlice(0)
+	a.True(change.Allowed())
+	a.Equal("50.00", change.Penalty.Number())
+
+	change = e.ChangeSlice(1)
+	a.True(change.Allowed())
+	a.Equal("75.00", change.Penalty.Number())
+	a.Equal("GBP", change.Penalty.CurrencyCode())
+
+	worst := e.Change()
+	a.True(worst.Allowed())
+	a.True(worst.PenaltyKnown)
+	a.Equal("75.00", worst.Penalty.Number())
+
+	a.Equal(PermissionUnknown, e.ChangeSlice(2).Permission)
+
+	// After the outbound departs it can no longer be changed.
+	later := e.At(time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC))
+	a.Equal(PermissionNotAllowed, later.ChangeSlice(0).Permission)
+	a.True(later.ChangeSlice(1).Allowed())
+	a.Equal(PermissionNotAllowed, later.Change().Permission)
+}
+
+func TestEvaluateConditionsUnknown(t *testing.T) {
+	a := assert.New(t)
+
+	offer := &Offer{
+		Conditions: Conditions{
+			RefundBeforeDeparture: &ChangeCondition{Allowed: true},
+		},
+		Slices: []Slice{testConditionsSlice("2024-03-10T18:00:00", SliceConditions{})},
+	}
+
+	e := EvaluateOfferConditions(offer)
+
+	refund := e.Refund()
+	a.True(refund.Allowed())
+	a.False(refund.PenaltyKnown)
+	a.False(refund.Free())
+
+	change := e.Change()
+	a.Equal(PermissionUnknown, change.Permission)
+	a.Equal("the airline did not provide conditions for changes", change.Reason)
+}
+
+func TestEvaluateOrderConditions(t *testing.T) {
+	a := assert.New(t)
+
+	order := &Order{
+		Conditions: Conditions{
+			RefundBeforeDeparture: penaltyCondition("0.00"),
+			ChangeBeforeDeparture: penaltyCondition("25.00"),
+		},
+		Slices: []Slice{testConditionsSlice("2024-03-10T18:00:00", SliceConditions{})},
+	}
+
+	e := EvaluateOrderConditions(order)
+	a.True(e.Refund().Free())
+	a.True(e.At(time.Date(2024, 3, 10, 17, 0, 0, 0, time.UTC)).Refund().Free())
+	a.Equal(PermissionNotAllowed, e.At(time.Date(2024, 3, 10, 18, 0, 0, 0, time.UTC)).Refund().Permission)
+
+	order.Slices[0].Changeable = false
+	a.Equal("the slice is not changeable", EvaluateOrderConditions(order).ChangeSlice(0).Reason)
+
+	cancelledAt := time.Now()
+	order.CancelledAt = &cancelledAt
+	a.Equal(PermissionNotAllowed, EvaluateOrderConditions(order).Refund().Permission)
+}
+
+func TestConditionsRefundAmount(t *testing.T) {
+	a := assert.New(t)
+
+	offer := &Offer{
+		RawTotalAmount:   "200.00",
+		RawTotalCurrency: "GBP",
+		Conditions:       Conditions{RefundBeforeDeparture: penaltyCondition("50.00")},
+	}
+	refund := EvaluateOfferConditions(offer).Refund()
+	a.True(refund.RefundAmountKnown)
+	a.Equal("150.00 GBP", refund.RefundAmount.String())
+
+	// The refund can't be negative.
+	offer.Conditions.RefundBeforeDeparture = penaltyCondition("250.00")
+	refund = EvaluateOfferConditions(offer).Refund()
+	a.True(refund.RefundAmountKnown)
+	a.True(refund.RefundAmount.IsZero())
+
+	// A penalty in another currency can't be taken off the total.
+	amount, eur := "50.00", "EUR"
+	offer.Conditions.RefundBeforeDeparture = &ChangeCondition{Allowed: true, RawPenaltyAmount: &amount, RawPenaltyCurrency: &eur}
+	refund = EvaluateOfferConditions(offer).Refund()
+	a.True(refund.PenaltyKnown)
+	a.False(refund.RefundAmountKnown)
+
+	offer.Conditions.RefundBeforeDeparture = &ChangeCondition{Allowed: true}
+	a.False(EvaluateOfferConditions(offer).Refund().RefundAmountKnown)
+}
+
+func TestConditionsChangeMixedCurrencies(t *testing.T) {
+	a := assert.New(t)
+
+	amount, eur := "10.00", "EUR"
+	offer := &Offer{
+		Slices: []Slice{
+			testConditionsSlice("2024-03-10T18:00:00", SliceConditions{ChangeBeforeDeparture: penaltyCondition("75.00")}),
+			testConditionsSlice("2024-03-17T18:00:00", SliceConditions{ChangeBeforeDeparture: &ChangeCondition{
+				Allowed: true, RawPenaltyAmount: &amount, RawPenaltyCurrency: &eur,
+			}}),
+		},
+	}
+
+	change := EvaluateOfferConditions(offer).Change()
+	a.True(change.Allowed())
+	a.False(change.PenaltyKnown)
+	a.Equal("changes are allowed, but the slices' penalties are in different currencies", change.Reason)
+}
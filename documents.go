@@ -0,0 +1,142 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+type (
+	// PassengerTickets ties the documents issued for an order to a single passenger,
+	// along with the segments and services those documents cover.
+	PassengerTickets struct {
+		Passenger *OrderPassenger
+
+		// The e-ticket numbers issued for the passenger.
+		TicketNumbers []string
+
+		// The EMDs issued for the passenger's services.
+		EMDs []Document
+
+		// The segments the passenger is travelling on, which their e-tickets cover.
+		Segments []*Flight
+
+		// The services booked for the passenger, which their EMDs cover.
+		Services []*Service
+	}
+)
+
+func (t DocumentType) String() string {
+	return string(t)
+}
+
+// DocumentType returns the type of the document.
+func (d Document) DocumentType() DocumentType {
+	return DocumentType(d.Type)
+}
+
+// IsElectronicTicket returns true if the document is an e-ticket.
+func (d Document) IsElectronicTicket() bool {
+	return d.DocumentType() == DocumentTypeElectronicTicket
+}
+
+// IsEMD returns true if the document is an electronic miscellaneous document, issued for services.
+func (d Document) IsEMD() bool {
+	t := d.DocumentType()
+	return t == DocumentTypeEMDAssociated || t == DocumentTypeEMDStandalone
+}
+
+// CoversPassenger returns true if the document was issued for the given passenger.
+func (d Document) CoversPassenger(passengerID string) bool {
+	return containsString(d.PassengerIDs, passengerID)
+}
+
+// DocumentsForPassenger returns the documents that cover the given passenger.
+//
+// Documents without passenger IDs are only attributed to a passenger
+// when the order has a single passenger.
+func (o *Order) DocumentsForPassenger(passengerID string) []Document {
+	docs := make([]Document, 0)
+	for _, d := range o.Documents {
+		if d.CoversPassenger(passengerID) || (len(d.PassengerIDs) == 0 && len(o.Passengers) == 1) {
+			docs = append(docs, d)
+		}
+	}
+	return docs
+}
+
+// TicketNumbers returns the e-ticket numbers issued for the given passenger.
+func (o *Order) TicketNumbers(passengerID string) []string {
+	numbers := make([]string, 0)
+	for _, d := range o.DocumentsForPassenger(passengerID) {
+		if d.IsElectronicTicket() {
+			numbers = append(numbers, d.UniqueIdentifier)
+		}
+	}
+	return numbers
+}
+
+// PassengerTickets maps the documents of the order to each of its passengers,
+// in the same order as Order.Passengers.
+func (o *Order) PassengerTickets() []PassengerTickets {
+	tickets := make([]PassengerTickets, 0, len(o.Passengers))
+
+	for i := range o.Passengers {
+		passenger := &o.Passengers[i]
+		pt := PassengerTickets{
+			Passenger:     passenger,
+			TicketNumbers: make([]string, 0),
+			EMDs:          make([]Document, 0),
+			Segments:      o.segmentsForPassenger(passenger.ID),
+			Services:      make([]*Service, 0),
+		}
+
+		for _, d := range o.DocumentsForPassenger(passenger.ID) {
+			switch {
+			case d.IsElectronicTicket():
+				pt.TicketNumbers = append(pt.TicketNumbers, d.UniqueIdentifier)
+			case d.IsEMD():
+				pt.EMDs = append(pt.EMDs, d)
+			}
+		}
+
+		for j := range o.Services {
+			if containsString(o.Services[j].PassengerIDs, passenger.ID) {
+				pt.Services = append(pt.Services, &o.Services[j])
+			}
+		}
+
+		tickets = append(tickets, pt)
+	}
+
+	return tickets
+}
+
+// segmentsForPassenger returns the segments the passenger is listed on.
+// Segments without any passenger details are assumed to carry every passenger.
+func (o *Order) segmentsForPassenger(passengerID string) []*Flight {
+	segments := make([]*Flight, 0)
+	for i := range o.Slices {
+		for j := range o.Slices[i].Segments {
+			segment := &o.Slices[i].Segments[j]
+			if len(segment.Passengers) == 0 {
+				segments = append(segments, segment)
+				continue
+			}
+			for _, p := range segment.Passengers {
+				if p.ID == passengerID {
+					segments = append(segments, segment)
+					break
+				}
+			}
+		}
+	}
+	return segments
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
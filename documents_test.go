@@ -0,0 +1,50 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"testing"
+
+	"github.com/segmentio/encoding/json"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderPassengerTickets(t *testing.T) {
+	a := assert.New(t)
+	order := new(Order)
+
+	err := json.Unmarshal([]byte(`{
+		"passengers": [{"id": "pas_1", "given_name": "Amelia"}, {"id": "pas_2", "given_name": "Fred"}],
+		"slices": [{"id": "sli_1", "segments": [
+			{"id": "seg_1", "passengers": [{"passenger_id": "pas_1"}, {"passenger_id": "pas_2"}]},
+			{"id": "seg_2", "passengers": [{"passenger_id": "pas_1"}]}
+		]}],
+		"services": [{"id": "ser_1", "type": "seat", "passenger_ids": ["pas_2"], "segment_ids": ["seg_1"]}],
+		"documents": [
+			{"type": "electronic_ticket", "unique_identifier": "1252106312810", "passenger_ids": ["pas_1"]},
+			{"type": "electronic_ticket", "unique_identifier": "1252106312811", "passenger_ids": ["pas_2"]},
+			{"type": "electronic_miscellaneous_document_associated", "unique_identifier": "1259876543210", "passenger_ids": ["pas_2"]}
+		]
+	}`), order)
+	a.NoError(err)
+
+	a.Equal([]string{"1252106312810"}, order.TicketNumbers("pas_1"))
+
+	tickets := order.PassengerTickets()
+	a.Len(tickets, 2)
+
+	a.Equal("Amelia", tickets[0].Passenger.GivenName)
+	a.Equal([]string{"1252106312810"}, tickets[0].TicketNumbers)
+	a.Len(tickets[0].Segments, 2)
+	a.Empty(tickets[0].EMDs)
+
+	a.Equal([]string{"1252106312811"}, tickets[1].TicketNumbers)
+	a.Len(tickets[1].Segments, 1)
+	a.Equal("seg_1", tickets[1].Segments[0].ID)
+	a.Len(tickets[1].EMDs, 1)
+	a.Equal(DocumentTypeEMDAssociated, tickets[1].EMDs[0].DocumentType())
+	a.Len(tickets[1].Services, 1)
+	a.Equal("ser_1", tickets[1].Services[0].ID)
+}
@@ -6,6 +6,7 @@ package duffel
 
 import (
 	"net/http"
+	"sync"
 	"time"
 
 	"golang.org/x/time/rate"
@@ -27,6 +28,7 @@ type (
 		AirlinesClient
 		AircraftClient
 		PlacesClient
+		LoyaltyProgrammesClient
 
 		LastRequestID() (string, bool)
 	}
@@ -238,6 +240,9 @@ type (
 		UserAgent string
 		HttpDoer  *http.Client
 		Debug     bool
+
+		// StreamingDecode decodes list items one at a time. See WithStreamingDecode.
+		StreamingDecode bool
 	}
 
 	client[Req any, Resp any] struct {
@@ -250,9 +255,17 @@ type (
 	}
 
 	API struct {
-		httpDoer      *http.Client
-		APIToken      string
-		options       *Options
+		httpDoer *http.Client
+		APIToken string
+		options  *Options
+
+		// limiter is shared by every request made with the API, so that concurrent
+		// requests stay within the rate limit together.
+		limiter *rate.Limiter
+
+		// mu guards lastRequestID, which is written by prefetching iterators
+		// and concurrent requests.
+		mu            sync.RWMutex
 		lastRequestID string
 	}
 )
@@ -301,13 +314,29 @@ func New(apiToken string, opts ...Option) Duffel {
 		httpDoer: options.HttpDoer,
 		APIToken: apiToken,
 		options:  options,
+		limiter:  rate.NewLimiter(rate.Every(1*time.Second), 5),
 	}
 }
 
+// RateLimiter returns the rate limiter shared by every request made with the API. It is
+// adjusted to the rate limit reported by Duffel as responses are received. Requests wait
+// on it themselves, so it only needs to be waited on to pace work other than requests.
+func (a *API) RateLimiter() *rate.Limiter {
+	return a.limiter
+}
+
 func (a *API) LastRequestID() (string, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	return a.lastRequestID, a.lastRequestID != ""
 }
 
+func (a *API) setLastRequestID(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lastRequestID = id
+}
+
 // Assert that our interface matches
 var (
 	_ Duffel = (*API)(nil)
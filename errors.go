@@ -173,11 +173,20 @@ func (e *DuffelError) IsCode(t ErrorCode) bool {
 }
 
 type Error struct {
-	Type             ErrorType `json:"type"`
-	Title            string    `json:"title"`
-	Message          string    `json:"message"`
-	DocumentationURL string    `json:"documentation_url"`
-	Code             ErrorCode `json:"code"`
+	Type             ErrorType    `json:"type"`
+	Title            string       `json:"title"`
+	Message          string       `json:"message"`
+	DocumentationURL string       `json:"documentation_url"`
+	Code             ErrorCode    `json:"code"`
+	Source           *ErrorSource `json:"source,omitempty"`
+}
+
+// ErrorSource points to the field of the request that caused a validation error.
+type ErrorSource struct {
+	// Field is the name of the field, e.g. "phone_number".
+	Field string `json:"field,omitempty"`
+	// Pointer is a JSON pointer to the field within the request data, e.g. "/passengers/0/phone_number".
+	Pointer string `json:"pointer,omitempty"`
 }
 
 type ErrorMeta struct {
@@ -1,31 +1,50 @@
 package duffel
 
-import "time"
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrMissingTimeZone is returned when a flight's local time can't be
+// interpreted because its airport has no time zone.
+var ErrMissingTimeZone = fmt.Errorf("missing time zone")
+
+const localTimeFormat = "2006-01-02T15:04:05"
+
+// locations caches loaded time zones, as time.LoadLocation reads the zone database every time it is called.
+var locations sync.Map
 
 func (f *Flight) DepartingAt() (time.Time, error) {
-	loc, err := time.LoadLocation(f.Origin.TimeZone)
-	if err != nil {
-		return time.Time{}, err
+	return parseLocalTime(f.RawDepartingAt, f.Origin)
+}
+
+func (f *Flight) ArrivingAt() (time.Time, error) {
+	return parseLocalTime(f.RawArrivingAt, f.Destination)
+}
+
+func parseLocalTime(raw string, place Location) (time.Time, error) {
+	if place.TimeZone == "" {
+		return time.Time{}, fmt.Errorf("%w for %s", ErrMissingTimeZone, place.IATACode)
 	}
 
-	t, err := time.ParseInLocation("2006-01-02T15:04:05", f.RawDepartingAt, loc)
+	loc, err := loadLocation(place.TimeZone)
 	if err != nil {
 		return time.Time{}, err
 	}
 
-	return t, err
+	return time.ParseInLocation(localTimeFormat, raw, loc)
 }
 
-func (f *Flight) ArrivingAt() (time.Time, error) {
-	loc, err := time.LoadLocation(f.Destination.TimeZone)
-	if err != nil {
-		return time.Time{}, err
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
 	}
 
-	t, err := time.ParseInLocation("2006-01-02T15:04:05", f.RawArrivingAt, loc)
+	loc, err := time.LoadLocation(name)
 	if err != nil {
-		return time.Time{}, err
+		return nil, err
 	}
-
-	return t, err
+	locations.Store(name, loc)
+	return loc, nil
 }
@@ -0,0 +1,180 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	earthRadiusKm = 6371.0
+
+	// kmPerDegreeLatitude is the approximate distance covered by one degree of latitude.
+	kmPerDegreeLatitude = 111.2
+
+	// geoCellSize is the size in degrees of each cell of the airport grid index.
+	geoCellSize = 1.0
+)
+
+type (
+	// AirportIndex is a spatial index over a set of airports, bucketing them
+	// into a grid of 1° cells so that nearby airports can be found without
+	// measuring the distance to every airport.
+	AirportIndex struct {
+		cells map[geoCell][]*Airport
+	}
+
+	// AirportDistance is an airport along with its distance from a point.
+	AirportDistance struct {
+		Airport    *Airport
+		DistanceKm float64
+	}
+
+	// BoundingBox is an area bounded by two latitudes and two longitudes.
+	// If MinLongitude is greater than MaxLongitude the box crosses the antimeridian.
+	BoundingBox struct {
+		MinLatitude  float64
+		MinLongitude float64
+		MaxLatitude  float64
+		MaxLongitude float64
+	}
+
+	geoCell struct {
+		lat int
+		lon int
+	}
+)
+
+// HaversineKm returns the great-circle distance in kilometres between two points.
+func HaversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// NewAirportIndex returns a spatial index over the given airports.
+func NewAirportIndex(airports []*Airport) *AirportIndex {
+	idx := &AirportIndex{
+		cells: make(map[geoCell][]*Airport),
+	}
+	for _, a := range airports {
+		if a == nil {
+			continue
+		}
+		cell := cellFor(float64(a.Latitude), float64(a.Longitude))
+		idx.cells[cell] = append(idx.cells[cell], a)
+	}
+	return idx
+}
+
+// Nearest returns the airports within radiusKm of the point, nearest first.
+// At most limit airports are returned, or all of them if limit is 0.
+func (idx *AirportIndex) Nearest(lat, lon, radiusKm float64, limit int) []AirportDistance {
+	dLat := radiusKm / kmPerDegreeLatitude
+	minLat, maxLat := math.Max(-90, lat-dLat), math.Min(90, lat+dLat)
+
+	// Longitude degrees shrink towards the poles, so widen the search to match.
+	// Near the poles, or for very large radii, every longitude has to be searched.
+	minLon, maxLon := -180.0, 180.0
+	if cos := math.Cos(toRadians(math.Max(math.Abs(minLat), math.Abs(maxLat)))); cos > 0 {
+		if dLon := dLat / cos; dLon < 180 {
+			minLon, maxLon = wrapLongitude(lon-dLon), wrapLongitude(lon+dLon)
+		}
+	}
+
+	results := make([]AirportDistance, 0)
+	idx.eachCandidate(BoundingBox{minLat, minLon, maxLat, maxLon}, func(a *Airport) {
+		if d := HaversineKm(lat, lon, float64(a.Latitude), float64(a.Longitude)); d <= radiusKm {
+			results = append(results, AirportDistance{Airport: a, DistanceKm: d})
+		}
+	})
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].DistanceKm < results[j].DistanceKm
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// WithinBounds returns the airports inside the bounding box.
+func (idx *AirportIndex) WithinBounds(box BoundingBox) []*Airport {
+	results := make([]*Airport, 0)
+	idx.eachCandidate(box, func(a *Airport) {
+		if box.Contains(float64(a.Latitude), float64(a.Longitude)) {
+			results = append(results, a)
+		}
+	})
+	return results
+}
+
+// Contains reports whether the point is inside the bounding box.
+func (b BoundingBox) Contains(lat, lon float64) bool {
+	if lat < b.MinLatitude || lat > b.MaxLatitude {
+		return false
+	}
+	if b.MinLongitude <= b.MaxLongitude {
+		return lon >= b.MinLongitude && lon <= b.MaxLongitude
+	}
+	return lon >= b.MinLongitude || lon <= b.MaxLongitude
+}
+
+// eachCandidate calls fn for every airport in the grid cells overlapping the box.
+func (idx *AirportIndex) eachCandidate(box BoundingBox, fn func(*Airport)) {
+	minCell := cellFor(box.MinLatitude, box.MinLongitude)
+	maxCell := cellFor(box.MaxLatitude, box.MaxLongitude)
+
+	lonCells := []int{}
+	if box.MinLongitude <= box.MaxLongitude {
+		for lon := minCell.lon; lon <= maxCell.lon; lon++ {
+			lonCells = append(lonCells, lon)
+		}
+	} else {
+		last := cellFor(0, 180).lon
+		first := cellFor(0, -180).lon
+		for lon := minCell.lon; lon <= last; lon++ {
+			lonCells = append(lonCells, lon)
+		}
+		for lon := first; lon <= maxCell.lon; lon++ {
+			lonCells = append(lonCells, lon)
+		}
+	}
+
+	for lat := minCell.lat; lat <= maxCell.lat; lat++ {
+		for _, lon := range lonCells {
+			for _, a := range idx.cells[geoCell{lat: lat, lon: lon}] {
+				fn(a)
+			}
+		}
+	}
+}
+
+func cellFor(lat, lon float64) geoCell {
+	return geoCell{
+		lat: int(math.Floor(lat / geoCellSize)),
+		lon: int(math.Floor(lon / geoCellSize)),
+	}
+}
+
+func wrapLongitude(lon float64) float64 {
+	for lon > 180 {
+		lon -= 360
+	}
+	for lon < -180 {
+		lon += 360
+	}
+	return lon
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
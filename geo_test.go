@@ -0,0 +1,69 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testAirports() []*Airport {
+	return []*Airport{
+		{ID: "arp_lhr_gb", IATACode: "LHR", Latitude: 51.470311, Longitude: -0.458118},
+		{ID: "arp_lgw_gb", IATACode: "LGW", Latitude: 51.153662, Longitude: -0.182063},
+		{ID: "arp_stn_gb", IATACode: "STN", Latitude: 51.886267, Longitude: 0.241105},
+		{ID: "arp_cdg_fr", IATACode: "CDG", Latitude: 49.012779, Longitude: 2.55},
+		{ID: "arp_jfk_us", IATACode: "JFK", Latitude: 40.640556, Longitude: -73.778519},
+		{ID: "arp_suv_fj", IATACode: "SUV", Latitude: -18.043335, Longitude: 178.559201},
+		{ID: "arp_tvu_fj", IATACode: "TVU", Latitude: -16.690556, Longitude: -179.876667},
+	}
+}
+
+func TestHaversineKm(t *testing.T) {
+	a := assert.New(t)
+
+	a.InDelta(0, HaversineKm(51.47, -0.45, 51.47, -0.45), 0.001)
+	// Heathrow to JFK is roughly 5,540km.
+	a.InDelta(5540, HaversineKm(51.470311, -0.458118, 40.640556, -73.778519), 15)
+}
+
+func TestAirportIndexNearest(t *testing.T) {
+	a := assert.New(t)
+	idx := NewAirportIndex(testAirports())
+
+	// Central London.
+	nearest := idx.Nearest(51.5074, -0.1278, 100, 0)
+	a.Len(nearest, 3)
+	a.Equal("LHR", nearest[0].Airport.IATACode)
+	a.Equal("LGW", nearest[1].Airport.IATACode)
+	a.Equal("STN", nearest[2].Airport.IATACode)
+	a.InDelta(24, nearest[0].DistanceKm, 2)
+
+	nearest = idx.Nearest(51.5074, -0.1278, 500, 2)
+	a.Len(nearest, 2)
+
+	a.Empty(idx.Nearest(0, 0, 100, 0))
+
+	// Across the antimeridian.
+	nearest = idx.Nearest(-17.5, 179.9, 300, 0)
+	a.Len(nearest, 2)
+	a.Equal("TVU", nearest[0].Airport.IATACode)
+}
+
+func TestAirportIndexWithinBounds(t *testing.T) {
+	a := assert.New(t)
+	idx := NewAirportIndex(testAirports())
+
+	airports := idx.WithinBounds(BoundingBox{MinLatitude: 48, MinLongitude: -1, MaxLatitude: 52, MaxLongitude: 3})
+	a.Len(airports, 4)
+
+	airports = idx.WithinBounds(BoundingBox{MinLatitude: 51, MinLongitude: -1, MaxLatitude: 52, MaxLongitude: 0})
+	a.Len(airports, 2)
+
+	// Across the antimeridian.
+	airports = idx.WithinBounds(BoundingBox{MinLatitude: -20, MinLongitude: 178, MaxLatitude: -15, MaxLongitude: -179})
+	a.Len(airports, 2)
+}
@@ -4,6 +4,14 @@
 
 package duffel
 
+import (
+	"context"
+	"fmt"
+)
+
+// maxListLimit is the largest page size accepted by the API.
+const maxListLimit = 200
+
 // Iter is an iterator for a list of items.
 // Based on the iterator used in https://github.com/stripe/stripe-go
 type Iter[T any] struct {
@@ -13,6 +21,47 @@ type Iter[T any] struct {
 	meta     *ListMeta
 	nextPage PageFn[T]
 	values   []*T
+
+	// nextPageContext is used instead of nextPage by iterators created with a context.
+	nextPageContext func(ctx context.Context, meta *ListMeta) (*List[T], error)
+
+	// cursor is the position the current page was requested from.
+	cursor ListOptions
+
+	// stream decodes the remaining items of the current page when streaming decoding is enabled.
+	stream *streamDecoder[T]
+
+	// prefetch enables fetching the next page in the background
+	// while the current page is being consumed.
+	prefetch bool
+	pending  chan pageResult[T]
+	closed   bool
+
+	// ctx is derived from parentCtx for the pages requested by the iterator. It is
+	// cancelled once the iterator stops, and derived again if paging resumes.
+	parentCtx context.Context
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	// Iterators derived from another iterator using Filter, Map, Take or Batch
+	// pull their items from the parent instead of fetching pages.
+	pull   func() (*T, bool)
+	parent iterParent
+}
+
+// iterParent is the part of Iter a derived iterator delegates to,
+// regardless of the parent's item type.
+type iterParent interface {
+	Err() error
+	LastRequestID() (string, bool)
+	Cursor() (ListOptions, bool)
+	Close()
+}
+
+type pageResult[T any] struct {
+	list   *List[T]
+	err    error
+	cursor ListOptions
 }
 
 func Collect[T any](it *Iter[T]) ([]*T, error) {
@@ -47,10 +96,19 @@ func (it *Iter[T]) Err() error {
 	if it == nil {
 		return nil
 	}
+	if it.err == nil && it.parent != nil {
+		return it.parent.Err()
+	}
 	return it.err
 }
 
 func (it *Iter[T]) LastRequestID() (string, bool) {
+	if it.parent != nil {
+		return it.parent.LastRequestID()
+	}
+	if it.list == nil {
+		return "", false
+	}
 	return it.list.LastRequestID()
 }
 
@@ -74,14 +132,44 @@ func (it *Iter[T]) Meta() *ListMeta {
 // It returns false when the iterator stops
 // at the end of the list.
 func (it *Iter[T]) Next() bool {
-	if it.err != nil {
+	if it.next() {
+		return true
+	}
+	it.release()
+	return false
+}
+
+func (it *Iter[T]) next() bool {
+	if it.err != nil || it.closed {
 		return false
 	}
 
+	if it.pull != nil {
+		cur, ok := it.pull()
+		if !ok {
+			return false
+		}
+		it.cur = cur
+		return true
+	}
+
+	if len(it.values) == 0 && it.stream != nil {
+		if it.nextStreamed() {
+			return true
+		}
+		if it.err != nil {
+			return false
+		}
+	}
+
 	if len(it.values) == 0 && it.meta.HasMore() {
 		it.getPage()
 	}
 
+	if len(it.values) == 0 && it.stream != nil {
+		return it.nextStreamed()
+	}
+
 	if len(it.values) == 0 {
 		return false
 	}
@@ -90,22 +178,262 @@ func (it *Iter[T]) Next() bool {
 	return true
 }
 
+// Prefetch enables fetching page N+1 in the background while page N is being
+// consumed. Requests still go through the rate limiter and stop when the
+// context passed to the list method is cancelled.
+//
+// Call Close if you stop iterating before reaching the end of the list
+// so that any in-flight request is cancelled.
+func (it *Iter[T]) Prefetch() *Iter[T] {
+	if it == nil {
+		return nil
+	}
+
+	it.prefetch = true
+	it.prefetchNextPage()
+	return it
+}
+
+// NextPage discards any unvisited items on the current page and loads the next page,
+// whose items are then visited by Next. It returns false if there is no next page
+// or the request failed, in which case Err returns the error.
+func (it *Iter[T]) NextPage() bool {
+	if it.err != nil || it.closed || !it.meta.HasMore() {
+		return false
+	}
+
+	it.values = nil
+	it.releaseStream()
+	it.getPage()
+	return it.err == nil
+}
+
+// PrevPage discards any unvisited items on the current page and loads the page
+// before it using the `before` cursor, whose items are then visited by Next.
+// It returns false if there is no previous page or the request failed, in which
+// case Err returns the error.
+func (it *Iter[T]) PrevPage() bool {
+	if it.err != nil || it.closed || !it.meta.HasPrevious() {
+		return false
+	}
+
+	// Any prefetched page follows the current page, so it is of no use here. It is
+	// waited for so that only one request is in flight at a time.
+	if it.pending != nil {
+		<-it.pending
+		it.pending = nil
+	}
+	it.resume()
+	it.values = nil
+	it.releaseStream()
+	it.loadPage(it.fetch(ListOptions{Before: it.meta.Before, Limit: it.meta.Limit}))
+	return it.err == nil
+}
+
+// Cursor returns the position to resume iteration from, suitable for persisting
+// and passing back as the ListOptions of a later list call.
+//
+// Once every item on the current page has been visited, the cursor points at the
+// next page. Otherwise it points at the current page, so resuming will revisit
+// its items rather than skip any. It returns false once the end of the list has
+// been reached, as there is nothing left to resume.
+func (it *Iter[T]) Cursor() (ListOptions, bool) {
+	if it == nil {
+		return ListOptions{}, false
+	}
+	if it.parent != nil {
+		return it.parent.Cursor()
+	}
+
+	if len(it.values) == 0 && it.stream == nil && it.meta != nil && it.err == nil {
+		if !it.meta.HasMore() {
+			return ListOptions{}, false
+		}
+		return ListOptions{After: it.meta.After, Limit: it.meta.Limit}, true
+	}
+	return it.cursor, true
+}
+
+// Close stops the iterator and cancels any in-flight page request.
+// Next returns false once the iterator is closed.
+func (it *Iter[T]) Close() {
+	if it == nil || it.closed {
+		return
+	}
+
+	it.closed = true
+	it.values = nil
+	it.pending = nil
+	it.releaseStream()
+	it.release()
+	if it.parent != nil {
+		it.parent.Close()
+	}
+}
+
+// withContext sets the context pages are requested with.
+func (it *Iter[T]) withContext(ctx context.Context) {
+	it.parentCtx = ctx
+	it.ctx, it.cancel = context.WithCancel(ctx)
+}
+
+// context returns the context to request pages with.
+func (it *Iter[T]) context() context.Context {
+	if it.ctx == nil {
+		return context.Background()
+	}
+	return it.ctx
+}
+
+// release cancels the iterator's context once it has stopped.
+func (it *Iter[T]) release() {
+	if it.cancel != nil {
+		it.cancel()
+	}
+}
+
+// resume derives a new context after release, so that an iterator that reached
+// the end of the list can page backwards.
+func (it *Iter[T]) resume() {
+	if it.parentCtx != nil && it.ctx.Err() != nil && it.parentCtx.Err() == nil {
+		it.withContext(it.parentCtx)
+	}
+}
+
 func (it *Iter[T]) getPage() {
-	it.list, it.err = it.nextPage(it.meta)
-	if it.err == nil {
+	if it.pending != nil {
+		result := <-it.pending
+		it.pending = nil
+		it.loadPage(result)
+	} else {
+		it.loadPage(it.fetch(ListOptions{After: it.meta.After, Limit: it.meta.Limit}))
+	}
+}
+
+// fetch requests the page at the given cursor.
+func (it *Iter[T]) fetch(cursor ListOptions) pageResult[T] {
+	return fetchPage(it.pager(), cursor)
+}
+
+// pager returns the function to request pages with, bound to the iterator's current context.
+func (it *Iter[T]) pager() PageFn[T] {
+	if it.nextPageContext == nil {
+		return it.nextPage
+	}
+	ctx, pager := it.context(), it.nextPageContext
+	return func(meta *ListMeta) (*List[T], error) {
+		return pager(ctx, meta)
+	}
+}
+
+func fetchPage[T any](pager PageFn[T], cursor ListOptions) pageResult[T] {
+	list, err := pager(&ListMeta{
+		After:  cursor.After,
+		Before: cursor.Before,
+		Limit:  cursor.Limit,
+	})
+	return pageResult[T]{list: list, err: err, cursor: cursor}
+}
+
+func (it *Iter[T]) loadPage(result pageResult[T]) {
+	it.err = result.err
+	if it.err != nil {
+		// Resuming retries the page that failed.
+		it.cursor = result.cursor
+	} else {
+		it.list = result.list
 		it.values = it.list.GetItems()
+		it.stream = result.list.stream
 		it.meta = it.list.GetListMeta()
+		if it.meta == nil {
+			// A streamed page may list its meta after the data,
+			// in which case it is read once every item is decoded.
+			it.meta = &ListMeta{Limit: result.cursor.Limit}
+		}
+		it.cursor = result.cursor
+		it.prefetchNextPage()
 	}
 }
 
+// nextStreamed decodes the next item of a streamed page. Once the page is
+// exhausted its meta is used to continue pagination.
+func (it *Iter[T]) nextStreamed() bool {
+	if cur, ok := it.stream.next(); ok {
+		it.cur = cur
+		return true
+	}
+
+	if it.stream.err != nil {
+		it.err = it.stream.err
+	} else if it.stream.meta != nil {
+		it.meta = it.stream.meta
+	}
+	it.stream = nil
+	it.prefetchNextPage()
+	return false
+}
+
+func (it *Iter[T]) releaseStream() {
+	if it.stream != nil {
+		it.stream.release()
+		it.stream = nil
+	}
+}
+
+func (it *Iter[T]) prefetchNextPage() {
+	if !it.prefetch || it.closed || it.err != nil || it.pending != nil || !it.meta.HasMore() {
+		return
+	}
+
+	// Buffered so that the goroutine can always exit, even if the iterator is closed
+	// before the page is consumed.
+	pending := make(chan pageResult[T], 1)
+	pager, cursor := it.pager(), ListOptions{After: it.meta.After, Limit: it.meta.Limit}
+	go func() {
+		pending <- fetchPage(pager, cursor)
+	}()
+	it.pending = pending
+}
+
 // GetIter returns a new Iter for a given query and type.
 func GetIter[T any](pager PageFn[T]) *Iter[T] {
+	return GetIterFrom(pager, ListOptions{})
+}
+
+// GetIterFrom returns a new Iter for a given query and type,
+// starting from the position described by opts.
+func GetIterFrom[T any](pager PageFn[T], opts ListOptions) *Iter[T] {
+	if opts.Limit < 0 || opts.Limit > maxListLimit {
+		return ErrIter[T](fmt.Errorf("limit must be between 1 and %d, got %d", maxListLimit, opts.Limit))
+	}
+
 	iter := &Iter[T]{
 		nextPage: pager,
 		meta:     &ListMeta{},
 	}
 
-	iter.getPage()
+	iter.loadPage(iter.fetch(opts))
+
+	return iter
+}
+
+// getIterContext returns a new Iter that requests pages with a context derived from ctx,
+// which is cancelled once the iterator stops.
+func getIterContext[T any](ctx context.Context, pager func(ctx context.Context, meta *ListMeta) (*List[T], error), opts ListOptions) *Iter[T] {
+	if opts.Limit < 0 || opts.Limit > maxListLimit {
+		return ErrIter[T](fmt.Errorf("limit must be between 1 and %d, got %d", maxListLimit, opts.Limit))
+	}
+
+	iter := &Iter[T]{
+		nextPageContext: pager,
+		meta:            &ListMeta{},
+	}
+	iter.withContext(ctx)
+
+	iter.loadPage(iter.fetch(opts))
+	if iter.err != nil {
+		iter.release()
+	}
 
 	return iter
 }
@@ -0,0 +1,41 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+//go:build go1.23
+
+package duffel
+
+import "iter"
+
+// All returns a range-over-func iterator over the remaining items in the list.
+// Pages are fetched lazily, so breaking out of the loop stops pagination early.
+// The iterator is closed once the loop ends.
+//
+// If the iterator stops because of an error, the final pair yielded
+// is a nil item with the error:
+//
+//	for airport, err := range dfl.ListAirports(ctx).All() {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(airport.Name)
+//	}
+func (it *Iter[T]) All() iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		if it == nil {
+			return
+		}
+		defer it.Close()
+
+		for it.Next() {
+			if !yield(it.Current(), nil) {
+				return
+			}
+		}
+
+		if err := it.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+//go:build go1.23
+
+package duffel
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestIterAll(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+
+	mockListOrdersPages()
+
+	ctx := context.TODO()
+	client := New("duffel_test_123")
+
+	refs := []string{}
+	for order, err := range client.ListOrders(ctx).All() {
+		a.NoError(err)
+		refs = append(refs, order.BookingReference)
+	}
+
+	a.Equal([]string{"RZPNX8", "ABC123"}, refs)
+	a.True(gock.IsDone())
+}
+
+func TestIterAllBreakStopsPagination(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+
+	gock.New("https://api.duffel.com").
+		Get("/air/orders").
+		Reply(200).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/200-list-orders.json")
+
+	ctx := context.TODO()
+	client := New("duffel_test_123")
+
+	count := 0
+	for _, err := range client.ListOrders(ctx).All() {
+		a.NoError(err)
+		count++
+		break
+	}
+
+	a.Equal(1, count)
+	a.False(gock.HasUnmatchedRequest())
+}
+
+func TestIterAllYieldsError(t *testing.T) {
+	a := assert.New(t)
+
+	var errs []error
+	for order, err := range ErrIter[Order](fmt.Errorf("boom")).All() {
+		a.Nil(order)
+		errs = append(errs, err)
+	}
+
+	a.Len(errs, 1)
+	a.EqualError(errs[0], "boom")
+}
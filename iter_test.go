@@ -0,0 +1,236 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func mockListOrdersPages() {
+	gock.New("https://api.duffel.com").
+		Get("/air/orders").
+		MatchParam("after", "g2wAAAACbQAAABBBZXJvbWlzdC1LaGFya2l2bQAAAB=").
+		Reply(200).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/200-list-orders-page2.json")
+
+	gock.New("https://api.duffel.com").
+		Get("/air/orders").
+		Reply(200).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/200-list-orders.json")
+}
+
+func TestIterPrefetch(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+	mockListOrdersPages()
+
+	ctx := context.TODO()
+	client := New("duffel_test_123")
+	iter := client.ListOrders(ctx).Prefetch()
+
+	// The second page is requested before the first page has been consumed.
+	a.Eventually(gock.IsDone, time.Second, 10*time.Millisecond)
+
+	a.True(iter.Next())
+	a.Equal("RZPNX8", iter.Current().BookingReference)
+
+	a.True(iter.Next())
+	a.Equal("ABC123", iter.Current().BookingReference)
+	a.Equal(&ListMeta{Limit: 50}, iter.Meta())
+
+	a.False(iter.Next())
+	a.NoError(iter.Err())
+
+	// The iterator's context is released at the end of the list without calling Close.
+	a.ErrorIs(iter.ctx.Err(), context.Canceled)
+}
+
+func TestIterClose(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+	mockListOrdersPages()
+
+	ctx := context.TODO()
+	client := New("duffel_test_123")
+	iter := client.ListOrders(ctx).Prefetch()
+
+	a.True(iter.Next())
+	iter.Close()
+
+	a.False(iter.Next())
+	a.NoError(iter.Err())
+}
+
+func TestIterPrefetchLastRequestID(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+	mockListOrdersPages()
+
+	ctx := context.TODO()
+	client := New("duffel_test_123")
+	iter := client.ListOrders(ctx).Prefetch()
+
+	// Reading the request ID while the next page is fetched in the background
+	// must not race, which go test -race checks.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for !gock.IsDone() {
+			client.LastRequestID()
+		}
+	}()
+
+	count := 0
+	for iter.Next() {
+		client.LastRequestID()
+		count++
+	}
+	<-done
+	a.NoError(iter.Err())
+	a.Equal(2, count)
+}
+
+func TestIterCursorAndPrevPage(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+
+	gock.New("https://api.duffel.com").
+		Get("/air/orders").
+		MatchParam("after", "g2wAAAACbQAAABBBZXJvbWlzdC1LaGFya2l2bQAAAB=").
+		MatchParam("booking_reference", "ABC123").
+		Reply(200).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/200-list-orders-page2-before.json")
+
+	gock.New("https://api.duffel.com").
+		Get("/air/orders").
+		MatchParam("before", "g3QAAAACZAACaWRtAAAAGm9yZF8wMDAwOWh0aGhzVVo4VzRMeFFna2pv").
+		Reply(200).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/200-list-orders.json")
+
+	ctx := context.TODO()
+	client := New("duffel_test_123")
+	iter := client.ListOrders(ctx, ListOrdersParams{
+		ListOptions:      ListOptions{After: "g2wAAAACbQAAABBBZXJvbWlzdC1LaGFya2l2bQAAAB="},
+		BookingReference: "ABC123",
+	})
+	a.NoError(iter.Err())
+
+	// Nothing visited yet, so resuming would start from the same page.
+	cursor, ok := iter.Cursor()
+	a.True(ok)
+	a.Equal(ListOptions{After: "g2wAAAACbQAAABBBZXJvbWlzdC1LaGFya2l2bQAAAB="}, cursor)
+
+	a.True(iter.Next())
+	a.Equal("ABC123", iter.Current().BookingReference)
+	a.False(iter.Next())
+	a.Error(iter.ctx.Err())
+
+	// The list has been fully consumed, so there is nothing to resume. Resuming
+	// with an empty cursor would start the list again from the beginning.
+	_, ok = iter.Cursor()
+	a.False(ok)
+
+	// Paging back after the end of the list derives a new context.
+	a.True(iter.PrevPage())
+	a.True(iter.Next())
+	a.Equal("RZPNX8", iter.Current().BookingReference)
+	cursor, ok = iter.Cursor()
+	a.True(ok)
+	a.Equal(ListOptions{After: "g2wAAAACbQAAABBBZXJvbWlzdC1LaGFya2l2bQAAAB=", Limit: 50}, cursor)
+	a.False(iter.PrevPage())
+	a.True(gock.IsDone())
+}
+
+func TestIterPrevPageWaitsForPrefetch(t *testing.T) {
+	a := assert.New(t)
+
+	var inFlight, maxInFlight int32
+	release := make(chan struct{})
+	iter := GetIterFrom(func(meta *ListMeta) (*List[Order], error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		if n > atomic.LoadInt32(&maxInFlight) {
+			atomic.StoreInt32(&maxInFlight, n)
+		}
+
+		list := new(List[Order])
+		switch {
+		case meta.After == "page3":
+			// The prefetch of the next page is still in flight when PrevPage is called.
+			<-release
+			list.SetListMeta(&ListMeta{Limit: 1, Before: "page2"})
+		case meta.Before == "page1":
+			list.SetListMeta(&ListMeta{Limit: 1, After: "page1"})
+		default:
+			list.SetListMeta(&ListMeta{Limit: 1, Before: "page1", After: "page3"})
+		}
+		id := "ord_after_" + meta.After
+		if meta.Before != "" {
+			id = "ord_before_" + meta.Before
+		}
+		list.SetItems([]*Order{{ID: id}})
+		return list, nil
+	}, ListOptions{Limit: 1, After: "page1"}).Prefetch()
+
+	a.Eventually(func() bool { return atomic.LoadInt32(&inFlight) == 1 }, time.Second, time.Millisecond)
+	time.AfterFunc(20*time.Millisecond, func() { close(release) })
+	a.True(iter.PrevPage())
+	a.True(iter.Next())
+	a.Equal("ord_before_page1", iter.Current().ID)
+	a.EqualValues(1, atomic.LoadInt32(&maxInFlight))
+}
+
+func TestIterLimit(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+
+	gock.New("https://api.duffel.com").
+		Get("/air/airlines").
+		MatchParam("limit", "10").
+		Reply(200).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/200-list-airlines.json")
+
+	ctx := context.TODO()
+	client := New("duffel_test_123")
+	iter := client.ListAirlines(ctx, ListAirlinesParams{
+		ListOptions: ListOptions{Limit: 10},
+	})
+
+	a.True(iter.Next())
+	a.NoError(iter.Err())
+	a.True(gock.IsDone())
+
+	iter = client.ListAirlines(ctx, ListAirlinesParams{
+		ListOptions: ListOptions{Limit: 500},
+	})
+	a.False(iter.Next())
+	a.EqualError(iter.Err(), "limit must be between 1 and 200, got 500")
+}
@@ -0,0 +1,151 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"context"
+	"sync"
+)
+
+// Filter returns an iterator over the items of it for which pred returns true.
+// Errors and request IDs are passed through from it.
+func Filter[T any](it *Iter[T], pred func(*T) bool) *Iter[T] {
+	return newDerivedIter(it, func() (*T, bool) {
+		for it.Next() {
+			if pred(it.Current()) {
+				return it.Current(), true
+			}
+		}
+		return nil, false
+	})
+}
+
+// Map returns an iterator over the result of calling fn on each item of it.
+// Errors and request IDs are passed through from it.
+func Map[T any, U any](it *Iter[T], fn func(*T) *U) *Iter[U] {
+	return newDerivedIter(it, func() (*U, bool) {
+		if !it.Next() {
+			return nil, false
+		}
+		return fn(it.Current()), true
+	})
+}
+
+// Take returns an iterator over at most the first n items of it.
+// Once n items have been visited no further pages are fetched.
+func Take[T any](it *Iter[T], n int) *Iter[T] {
+	taken := 0
+	return newDerivedIter(it, func() (*T, bool) {
+		if taken >= n {
+			it.Close()
+			return nil, false
+		}
+		if !it.Next() {
+			return nil, false
+		}
+		taken++
+		return it.Current(), true
+	})
+}
+
+// Batch returns an iterator over slices of up to size items of it.
+// Only the last batch may be smaller than size.
+//
+//	batches := duffel.Batch(dfl.ListOrders(ctx), 100)
+//	for batches.Next() {
+//		orders := *batches.Current()
+//		// ...
+//	}
+func Batch[T any](it *Iter[T], size int) *Iter[[]*T] {
+	if size < 1 {
+		size = 1
+	}
+
+	return newDerivedIter(it, func() (*[]*T, bool) {
+		batch := make([]*T, 0, size)
+		for len(batch) < size && it.Next() {
+			batch = append(batch, it.Current())
+		}
+		if len(batch) == 0 {
+			return nil, false
+		}
+		return &batch, true
+	})
+}
+
+// ForEachConcurrent calls fn for each item of it using up to workers goroutines.
+// Items are read from it on the calling goroutine, so pagination stays sequential.
+//
+// The first error returned by fn cancels the context passed to the other calls,
+// closes the iterator and is returned. Otherwise the iterator's error is returned,
+// or the context error if ctx was cancelled.
+func ForEachConcurrent[T any](ctx context.Context, it *Iter[T], workers int, fn func(ctx context.Context, item *T) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	items := make(chan *T)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range items {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := fn(ctx, item); err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+
+feed:
+	for {
+		// Stop before fetching more once an item has failed or ctx is done.
+		if ctx.Err() != nil || !it.Next() {
+			break
+		}
+		select {
+		case items <- it.Current():
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(items)
+	wg.Wait()
+
+	if firstErr != nil {
+		it.Close()
+		return firstErr
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+func newDerivedIter[T any](parent iterParent, pull func() (*T, bool)) *Iter[T] {
+	return &Iter[T]{
+		meta:   &ListMeta{},
+		pull:   pull,
+		parent: parent,
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pagedIter returns an iterator over 1..n in pages of pageSize,
+// counting the number of pages fetched.
+func pagedIter(n, pageSize int, fetched *int) *Iter[int] {
+	return GetIter(func(meta *ListMeta) (*List[int], error) {
+		*fetched++
+		start := 0
+		if meta.After != "" {
+			start, _ = strconv.Atoi(meta.After)
+		}
+
+		list := new(List[int])
+		items := []*int{}
+		for i := start; i < start+pageSize && i < n; i++ {
+			v := i + 1
+			items = append(items, &v)
+		}
+		next := &ListMeta{}
+		if start+pageSize < n {
+			next.After = strconv.Itoa(start + pageSize)
+		}
+		list.SetItems(items)
+		list.SetListMeta(next)
+		list.setRequestID(fmt.Sprintf("req_%d", *fetched))
+		return list, nil
+	})
+}
+
+func values(it *Iter[int]) []int {
+	out := []int{}
+	for it.Next() {
+		out = append(out, *it.Current())
+	}
+	return out
+}
+
+func TestFilterMapTake(t *testing.T) {
+	a := assert.New(t)
+	fetched := 0
+
+	even := Filter(pagedIter(20, 5, &fetched), func(v *int) bool { return *v%2 == 0 })
+	doubled := Map(even, func(v *int) *int { d := *v * 2; return &d })
+	first := Take(doubled, 3)
+
+	a.Equal([]int{4, 8, 12}, values(first))
+	a.NoError(first.Err())
+	a.Equal(2, fetched, "only the pages needed for 3 items are fetched")
+
+	reqID, ok := first.LastRequestID()
+	a.True(ok)
+	a.Equal("req_2", reqID)
+}
+
+func TestBatch(t *testing.T) {
+	a := assert.New(t)
+	fetched := 0
+
+	batches := Batch(pagedIter(7, 2, &fetched), 3)
+	sizes := []int{}
+	for batches.Next() {
+		sizes = append(sizes, len(*batches.Current()))
+	}
+	a.NoError(batches.Err())
+	a.Equal([]int{3, 3, 1}, sizes)
+}
+
+func TestDerivedIterPassesErrors(t *testing.T) {
+	a := assert.New(t)
+
+	it := Map(ErrIter[int](fmt.Errorf("boom")), func(v *int) *string { s := strconv.Itoa(*v); return &s })
+	a.False(it.Next())
+	a.EqualError(it.Err(), "boom")
+	_, ok := it.LastRequestID()
+	a.False(ok)
+}
+
+func TestForEachConcurrent(t *testing.T) {
+	a := assert.New(t)
+	fetched := 0
+
+	var mu sync.Mutex
+	sum := 0
+	err := ForEachConcurrent(context.TODO(), pagedIter(100, 10, &fetched), 4, func(ctx context.Context, v *int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sum += *v
+		return nil
+	})
+	a.NoError(err)
+	a.Equal(5050, sum)
+	a.Equal(10, fetched)
+
+	fetched = 0
+	it := pagedIter(100, 10, &fetched)
+	err = ForEachConcurrent(context.TODO(), it, 2, func(ctx context.Context, v *int) error {
+		if *v == 5 {
+			return fmt.Errorf("failed on %d", *v)
+		}
+		return nil
+	})
+	a.EqualError(err, "failed on 5")
+	a.False(it.Next(), "iterator is closed after a failure")
+	a.Less(fetched, 10)
+
+	// Once an item fails no more items are taken from the iterator, even though
+	// the workers are still free to take them.
+	var (
+		failedMu  sync.Mutex
+		failedCtx context.Context
+		late      int
+	)
+	fetched = 0
+	it = Filter(pagedIter(1000, 10, &fetched), func(v *int) bool {
+		failedMu.Lock()
+		defer failedMu.Unlock()
+		if failedCtx != nil && failedCtx.Err() != nil {
+			late++
+		}
+		return true
+	})
+	err = ForEachConcurrent(context.TODO(), it, 4, func(ctx context.Context, v *int) error {
+		if *v != 1 {
+			return nil
+		}
+		failedMu.Lock()
+		failedCtx = ctx
+		failedMu.Unlock()
+		return fmt.Errorf("failed on %d", *v)
+	})
+	a.EqualError(err, "failed on 1")
+	a.Zero(late)
+}
@@ -0,0 +1,211 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"strings"
+	"time"
+)
+
+type (
+	// AirportLookup finds an airport by its IATA code.
+	// It is implemented by ReferenceCache.
+	AirportLookup interface {
+		AirportByIATA(code string) (*Airport, bool)
+	}
+
+	// Itinerary is a view over the slices of an offer or order
+	// with the times of each segment resolved in their local time zones.
+	Itinerary struct {
+		Slices []*ItinerarySlice
+	}
+
+	// ItinerarySlice is a slice of an itinerary.
+	ItinerarySlice struct {
+		Slice    *Slice
+		Segments []*ItinerarySegment
+		Layovers []*Layover
+
+		// DepartingAt is the local departure time of the first segment.
+		DepartingAt time.Time
+		// ArrivingAt is the local arrival time of the last segment.
+		ArrivingAt time.Time
+		// Duration is the total travel time, including layovers.
+		Duration time.Duration
+		// DayChange is the number of days between the local departure and arrival dates,
+		// e.g. 1 for a slice arriving the day after it departs.
+		DayChange int
+	}
+
+	// ItinerarySegment is a single flight of an itinerary slice.
+	ItinerarySegment struct {
+		Flight *Flight
+
+		// DepartingAt is the departure time in the origin's time zone.
+		DepartingAt time.Time
+		// ArrivingAt is the arrival time in the destination's time zone.
+		ArrivingAt time.Time
+		// Duration is the time in the air.
+		Duration time.Duration
+		// DayChange is the number of days between the local departure and arrival dates.
+		// It is negative for flights arriving on an earlier date, such as when crossing
+		// the International Date Line eastwards.
+		DayChange int
+		// Overnight is true if the flight arrives on a later local date than it departs.
+		Overnight bool
+		// RedEye is true for flights departing between 21:00 and 02:00 and arriving
+		// between 04:00 and 10:00 local time.
+		RedEye bool
+	}
+
+	// Layover is the wait between two segments of a slice.
+	Layover struct {
+		// Airport is where the passenger arrives.
+		Airport Location
+		// DepartureAirport is where the next segment departs from,
+		// which differs from Airport when the passenger has to change airports.
+		DepartureAirport Location
+		Duration         time.Duration
+		// Overnight is true if the layover spans midnight at the connecting airport.
+		Overnight bool
+	}
+)
+
+// Itinerary returns an itinerary view over the offer's slices.
+// Time zones missing from the offer are looked up in airports, which may be nil.
+func (o *Offer) Itinerary(airports AirportLookup) (*Itinerary, error) {
+	return NewItinerary(o.Slices, airports)
+}
+
+// Itinerary returns an itinerary view over the order's slices.
+// Time zones missing from the order are looked up in airports, which may be nil.
+func (o *Order) Itinerary(airports AirportLookup) (*Itinerary, error) {
+	return NewItinerary(o.Slices, airports)
+}
+
+// NewItinerary returns an itinerary view over the slices.
+//
+// Segment times are given in the local time zone of each airport. When a location
+// doesn't include its time zone it is looked up by IATA code in airports, which
+// may be nil. ErrMissingTimeZone is returned if a time zone can't be found.
+func NewItinerary(slices []Slice, airports AirportLookup) (*Itinerary, error) {
+	it := &Itinerary{
+		Slices: make([]*ItinerarySlice, 0, len(slices)),
+	}
+
+	for i := range slices {
+		slice, err := newItinerarySlice(&slices[i], airports)
+		if err != nil {
+			return nil, err
+		}
+		it.Slices = append(it.Slices, slice)
+	}
+	return it, nil
+}
+
+// Duration returns the total travel time across all slices.
+func (it *Itinerary) Duration() time.Duration {
+	var total time.Duration
+	for _, s := range it.Slices {
+		total += s.Duration
+	}
+	return total
+}
+
+// Stops returns the number of connections within the slice.
+func (s *ItinerarySlice) Stops() int {
+	return len(s.Layovers)
+}
+
+// DepartingAtUTC returns the departure time in UTC.
+func (s *ItinerarySegment) DepartingAtUTC() time.Time {
+	return s.DepartingAt.UTC()
+}
+
+// ArrivingAtUTC returns the arrival time in UTC.
+func (s *ItinerarySegment) ArrivingAtUTC() time.Time {
+	return s.ArrivingAt.UTC()
+}
+
+func newItinerarySlice(slice *Slice, airports AirportLookup) (*ItinerarySlice, error) {
+	s := &ItinerarySlice{
+		Slice:    slice,
+		Segments: make([]*ItinerarySegment, 0, len(slice.Segments)),
+	}
+
+	for i := range slice.Segments {
+		segment, err := newItinerarySegment(&slice.Segments[i], airports)
+		if err != nil {
+			return nil, err
+		}
+
+		if n := len(s.Segments); n > 0 {
+			prev := s.Segments[n-1]
+			s.Layovers = append(s.Layovers, &Layover{
+				Airport:          prev.Flight.Destination,
+				DepartureAirport: segment.Flight.Origin,
+				Duration:         segment.DepartingAt.Sub(prev.ArrivingAt),
+				Overnight:        daysBetween(prev.ArrivingAt, segment.DepartingAt.In(prev.ArrivingAt.Location())) > 0,
+			})
+		}
+		s.Segments = append(s.Segments, segment)
+	}
+
+	if len(s.Segments) > 0 {
+		s.DepartingAt = s.Segments[0].DepartingAt
+		s.ArrivingAt = s.Segments[len(s.Segments)-1].ArrivingAt
+		s.Duration = s.ArrivingAt.Sub(s.DepartingAt)
+		s.DayChange = daysBetween(s.DepartingAt, s.ArrivingAt)
+	}
+	return s, nil
+}
+
+func newItinerarySegment(f *Flight, airports AirportLookup) (*ItinerarySegment, error) {
+	departingAt, err := parseLocalTime(f.RawDepartingAt, withTimeZone(f.Origin, airports))
+	if err != nil {
+		return nil, err
+	}
+	arrivingAt, err := parseLocalTime(f.RawArrivingAt, withTimeZone(f.Destination, airports))
+	if err != nil {
+		return nil, err
+	}
+
+	dayChange := daysBetween(departingAt, arrivingAt)
+	return &ItinerarySegment{
+		Flight:      f,
+		DepartingAt: departingAt,
+		ArrivingAt:  arrivingAt,
+		Duration:    arrivingAt.Sub(departingAt),
+		DayChange:   dayChange,
+		Overnight:   dayChange > 0,
+		RedEye:      isRedEye(departingAt, arrivingAt, dayChange),
+	}, nil
+}
+
+// isRedEye reports whether a flight departs late at night and arrives early in
+// the morning. Flights departing after midnight arrive on the same local date.
+func isRedEye(departingAt, arrivingAt time.Time, dayChange int) bool {
+	lateDeparture := (departingAt.Hour() >= 21 && dayChange > 0) || departingAt.Hour() < 2
+	return lateDeparture && arrivingAt.Hour() >= 4 && arrivingAt.Hour() < 10
+}
+
+// withTimeZone fills in the location's time zone from airports if it is missing.
+func withTimeZone(loc Location, airports AirportLookup) Location {
+	if loc.TimeZone != "" || airports == nil {
+		return loc
+	}
+	if airport, ok := airports.AirportByIATA(strings.ToUpper(loc.IATACode)); ok {
+		loc.TimeZone = airport.TimeZone
+	}
+	return loc
+}
+
+// daysBetween returns the number of calendar days between the dates of a and b,
+// each in their own time zone.
+func daysBetween(a, b time.Time) int {
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(db.Sub(da).Hours() / 24)
+}
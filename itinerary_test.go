@@ -0,0 +1,98 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testAirportLookup map[string]*Airport
+
+func (l testAirportLookup) AirportByIATA(code string) (*Airport, bool) {
+	a, ok := l[code]
+	return a, ok
+}
+
+func testItinerarySlices() []Slice {
+	lhr := Location{IATACode: "LHR", TimeZone: "Europe/London"}
+	jfk := Location{IATACode: "JFK", TimeZone: "America/New_York"}
+	// The payload omits the time zone for LAX.
+	lax := Location{IATACode: "LAX"}
+
+	return []Slice{
+		{
+			BaseSlice: &BaseSlice{Origin: lhr, Destination: lax},
+			Segments: []Flight{
+				{Origin: lhr, Destination: jfk, RawDepartingAt: "2024-03-10T18:00:00", RawArrivingAt: "2024-03-10T21:00:00"},
+				{Origin: jfk, Destination: lax, RawDepartingAt: "2024-03-10T23:30:00", RawArrivingAt: "2024-03-11T02:45:00"},
+			},
+		},
+		{
+			BaseSlice: &BaseSlice{Origin: lax, Destination: jfk},
+			Segments: []Flight{
+				{Origin: lax, Destination: jfk, RawDepartingAt: "2024-03-11T22:00:00", RawArrivingAt: "2024-03-12T06:30:00"},
+			},
+		},
+	}
+}
+
+func TestItinerary(t *testing.T) {
+	a := assert.New(t)
+
+	airports := testAirportLookup{"LAX": {IATACode: "LAX", TimeZone: "America/Los_Angeles"}}
+	it, err := NewItinerary(testItinerarySlices(), airports)
+	a.NoError(err)
+	a.Len(it.Slices, 2)
+
+	outbound := it.Slices[0]
+	a.Len(outbound.Segments, 2)
+	a.Equal(1, outbound.Stops())
+	a.Equal(7*time.Hour, outbound.Segments[0].Duration)
+	a.Equal(0, outbound.Segments[0].DayChange)
+	a.Equal(time.Date(2024, 3, 10, 18, 0, 0, 0, time.UTC), outbound.Segments[0].DepartingAtUTC())
+	a.Equal(time.Date(2024, 3, 11, 1, 0, 0, 0, time.UTC), outbound.Segments[0].ArrivingAtUTC())
+
+	a.Equal(6*time.Hour+15*time.Minute, outbound.Segments[1].Duration)
+	a.Equal(1, outbound.Segments[1].DayChange)
+	a.True(outbound.Segments[1].Overnight)
+	a.False(outbound.Segments[1].RedEye)
+	a.Equal("America/Los_Angeles", outbound.Segments[1].ArrivingAt.Location().String())
+
+	a.Len(outbound.Layovers, 1)
+	a.Equal("JFK", outbound.Layovers[0].Airport.IATACode)
+	a.Equal(150*time.Minute, outbound.Layovers[0].Duration)
+	a.False(outbound.Layovers[0].Overnight)
+
+	a.Equal(15*time.Hour+45*time.Minute, outbound.Duration)
+	a.Equal(1, outbound.DayChange)
+
+	inbound := it.Slices[1]
+	a.Equal(0, inbound.Stops())
+	a.True(inbound.Segments[0].RedEye)
+	a.Equal(5*time.Hour+30*time.Minute, inbound.Duration)
+
+	a.Equal(21*time.Hour+15*time.Minute, it.Duration())
+}
+
+func TestItineraryRedEyeAfterMidnight(t *testing.T) {
+	a := assert.New(t)
+
+	lax := Location{IATACode: "LAX", TimeZone: "America/Los_Angeles"}
+	jfk := Location{IATACode: "JFK", TimeZone: "America/New_York"}
+	segment, err := newItinerarySegment(&Flight{Origin: lax, Destination: jfk, RawDepartingAt: "2024-03-12T00:30:00", RawArrivingAt: "2024-03-12T08:45:00"}, nil)
+	a.NoError(err)
+	a.Equal(0, segment.DayChange)
+	a.False(segment.Overnight)
+	a.True(segment.RedEye)
+}
+
+func TestItineraryMissingTimeZone(t *testing.T) {
+	_, err := NewItinerary(testItinerarySlices(), nil)
+	assert.True(t, errors.Is(err, ErrMissingTimeZone))
+}
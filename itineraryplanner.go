@@ -0,0 +1,395 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bojanz/currency"
+	"golang.org/x/time/rate"
+)
+
+// ErrNoOffers is returned when an offer request comes back without any priced offers.
+var ErrNoOffers = errors.New("duffel: no offers found")
+
+type (
+	// ItineraryPlanner checks and prices journeys of any number of slices,
+	// such as open-jaw trips that fly into one city and home from another.
+	//
+	//	planner := duffel.NewItineraryPlanner(
+	//		duffel.WithCityResolver(resolver),
+	//		duffel.WithMinimumStay(2),
+	//	)
+	//	comparison, err := planner.Compare(ctx, client, input)
+	ItineraryPlanner struct {
+		resolver    *CityResolver
+		minStay     int
+		concurrency int
+		limiter     *rate.Limiter
+	}
+
+	// ItineraryPlannerOption configures an ItineraryPlanner.
+	ItineraryPlannerOption func(*ItineraryPlanner)
+
+	// ItineraryKind is the shape of a journey.
+	ItineraryKind string
+
+	// CompositeItinerary is a way of booking a journey: either one offer covering every
+	// slice, or one offer per slice booked as separate tickets.
+	CompositeItinerary struct {
+		// Offers are the cheapest offers found, in slice order.
+		Offers          []*Offer
+		OfferRequestIDs []string
+		TotalAmount     currency.Amount
+		// SeparateTickets is true if the journey is booked as more than one order, in which
+		// case a missed connection between slices is not protected by the airlines.
+		SeparateTickets bool
+	}
+
+	// ItineraryComparison compares booking a journey as a single multi-city offer
+	// against combining the cheapest one-way offer for each slice.
+	ItineraryComparison struct {
+		MultiCity    *CompositeItinerary
+		MultiCityErr error
+		// OneWays is nil for journeys of a single slice.
+		OneWays   *CompositeItinerary
+		OneWayErr error
+		// Cheapest is whichever of MultiCity and OneWays costs less. The multi-city
+		// itinerary is preferred when they cost the same or are priced in different currencies.
+		Cheapest *CompositeItinerary
+	}
+)
+
+const (
+	ItineraryKindOneWay ItineraryKind = "one_way"
+	ItineraryKindReturn ItineraryKind = "return"
+	// ItineraryKindOpenJaw is a two slice journey that returns from, or to,
+	// a different city than the one flown to, or from.
+	ItineraryKindOpenJaw   ItineraryKind = "open_jaw"
+	ItineraryKindMultiCity ItineraryKind = "multi_city"
+)
+
+// WithCityResolver resolves city names such as "London" to their city codes, rejects
+// codes that aren't known cities or airports, and compares slices by city so that
+// flying into LHR and home from LGW is a return journey rather than an open jaw.
+func WithCityResolver(resolver *CityResolver) ItineraryPlannerOption {
+	return func(p *ItineraryPlanner) {
+		p.resolver = resolver
+	}
+}
+
+// WithMinimumStay sets the number of days that must pass between the departure dates
+// of consecutive slices. By default, slices may depart on the same day.
+func WithMinimumStay(days int) ItineraryPlannerOption {
+	return func(p *ItineraryPlanner) {
+		p.minStay = days
+	}
+}
+
+// WithPlannerConcurrency sets how many offer requests Compare runs at once. It defaults to 4.
+func WithPlannerConcurrency(n int) ItineraryPlannerOption {
+	return func(p *ItineraryPlanner) {
+		p.concurrency = n
+	}
+}
+
+// WithPlannerRateLimiter sets a rate limiter to wait on before each offer request.
+// By default, requests made with an *API stay within the rate limit it shares between
+// all of its requests, and requests made with other clients are limited to 5 per second.
+func WithPlannerRateLimiter(limiter *rate.Limiter) ItineraryPlannerOption {
+	return func(p *ItineraryPlanner) {
+		p.limiter = limiter
+	}
+}
+
+// NewItineraryPlanner returns a planner configured with the options.
+func NewItineraryPlanner(opts ...ItineraryPlannerOption) *ItineraryPlanner {
+	p := &ItineraryPlanner{
+		concurrency: 4,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.concurrency < 1 {
+		p.concurrency = 1
+	}
+	return p
+}
+
+// Plan returns the input with the origin and destination of each slice normalized to
+// upper case IATA codes, or a *DuffelError listing every problem with its slices:
+//
+//   - there is at least one slice
+//   - origins and destinations are known three letter IATA codes, and differ
+//   - slices depart in chronological order, at least the minimum stay apart
+func (p *ItineraryPlanner) Plan(input OfferRequestInput) (OfferRequestInput, error) {
+	if len(input.Slices) == 0 {
+		return input, &DuffelError{Errors: []Error{itineraryError(&ErrorSource{Pointer: "/slices"}, "at least one slice is required")}}
+	}
+
+	var errs []Error
+	slices := make([]OfferRequestSlice, len(input.Slices))
+	for i, slice := range input.Slices {
+		var err *Error
+		slice.Origin, err = p.normalizeCode(slice.Origin, i, "origin")
+		if err != nil {
+			errs = append(errs, *err)
+		}
+		slice.Destination, err = p.normalizeCode(slice.Destination, i, "destination")
+		if err != nil {
+			errs = append(errs, *err)
+		}
+		if slice.Origin != "" && p.cityCode(slice.Origin) == p.cityCode(slice.Destination) {
+			errs = append(errs, itineraryError(sliceSource(i, "destination"),
+				fmt.Sprintf("slice %d departs from and arrives in %s", i+1, p.cityCode(slice.Origin))))
+		}
+
+		departure := time.Time(slice.DepartureDate)
+		switch {
+		case departure.IsZero():
+			errs = append(errs, itineraryError(sliceSource(i, "departure_date"), fmt.Sprintf("slice %d has no departure date", i+1)))
+		case i > 0 && !time.Time(slices[i-1].DepartureDate).IsZero():
+			previous := time.Time(slices[i-1].DepartureDate)
+			days := int(departure.Sub(previous).Hours() / 24)
+			if departure.Before(previous) {
+				errs = append(errs, itineraryError(sliceSource(i, "departure_date"),
+					fmt.Sprintf("slice %d departs on %s, before slice %d on %s", i+1, slice.DepartureDate, i, slices[i-1].DepartureDate)))
+			} else if days < p.minStay {
+				errs = append(errs, itineraryError(sliceSource(i, "departure_date"),
+					fmt.Sprintf("slice %d departs %s after slice %d, less than the minimum stay of %s",
+						i+1, pluralize(days, "day"), i, pluralize(p.minStay, "day"))))
+			}
+		}
+		slices[i] = slice
+	}
+
+	if len(errs) > 0 {
+		return input, &DuffelError{Errors: errs}
+	}
+	input.Slices = slices
+	return input, nil
+}
+
+// Kind returns the shape of the journey made by the slices, comparing their ends by city.
+func (p *ItineraryPlanner) Kind(slices []OfferRequestSlice) ItineraryKind {
+	switch len(slices) {
+	case 1:
+		return ItineraryKindOneWay
+	case 2:
+		out, back := slices[0], slices[1]
+		if p.cityCode(out.Destination) == p.cityCode(back.Origin) && p.cityCode(out.Origin) == p.cityCode(back.Destination) {
+			return ItineraryKindReturn
+		}
+		return ItineraryKindOpenJaw
+	}
+	return ItineraryKindMultiCity
+}
+
+// Compare plans the input, then searches for it both as a single multi-city offer
+// request and as a one-way offer request per slice, concurrently within the concurrency
+// cap and the rate limit shared by the client's requests. It returns the cheapest offer
+// from each approach and which is cheaper overall.
+//
+// If only one approach fails, its error is recorded in the comparison. If both fail,
+// or the input is invalid, an error is returned.
+func (p *ItineraryPlanner) Compare(ctx context.Context, client OfferRequestCreator, input OfferRequestInput) (*ItineraryComparison, error) {
+	input, err := p.Plan(input)
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		offer          *Offer
+		offerRequestID string
+		err            error
+	}
+	requests := []OfferRequestInput{input}
+	if len(input.Slices) > 1 {
+		for _, slice := range input.Slices {
+			oneWay := input
+			oneWay.Slices = []OfferRequestSlice{slice}
+			requests = append(requests, oneWay)
+		}
+	}
+
+	limiter := defaultLimiter(client, p.limiter)
+	results := make([]result, len(requests))
+	slots := make(chan struct{}, p.concurrency)
+	var wg sync.WaitGroup
+	for i := range requests {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			offerRequestID, offer, err := cheapestOffer(ctx, client, limiter, requests[i])
+			results[i] = result{offer: offer, offerRequestID: offerRequestID, err: err}
+		}(i)
+	}
+	wg.Wait()
+
+	comparison := &ItineraryComparison{}
+	if results[0].err != nil {
+		comparison.MultiCityErr = results[0].err
+	} else {
+		comparison.MultiCity = &CompositeItinerary{
+			Offers:          []*Offer{results[0].offer},
+			OfferRequestIDs: []string{results[0].offerRequestID},
+			TotalAmount:     results[0].offer.TotalAmount(),
+		}
+	}
+
+	if len(results) > 1 {
+		oneWays := &CompositeItinerary{SeparateTickets: true}
+		for i, r := range results[1:] {
+			if r.err != nil {
+				comparison.OneWayErr = fmt.Errorf("searching slice %d: %w", i+1, r.err)
+				break
+			}
+			oneWays.Offers = append(oneWays.Offers, r.offer)
+			oneWays.OfferRequestIDs = append(oneWays.OfferRequestIDs, r.offerRequestID)
+			if i == 0 {
+				oneWays.TotalAmount = r.offer.TotalAmount()
+				continue
+			}
+			if oneWays.TotalAmount, err = oneWays.TotalAmount.Add(r.offer.TotalAmount()); err != nil {
+				comparison.OneWayErr = fmt.Errorf("combining one-way offers: %w", err)
+				break
+			}
+		}
+		if comparison.OneWayErr == nil {
+			comparison.OneWays = oneWays
+		}
+	}
+
+	switch {
+	case comparison.MultiCity == nil && comparison.OneWays == nil:
+		return comparison, comparison.MultiCityErr
+	case comparison.MultiCity == nil:
+		comparison.Cheapest = comparison.OneWays
+	case comparison.OneWays == nil:
+		comparison.Cheapest = comparison.MultiCity
+	default:
+		comparison.Cheapest = comparison.MultiCity
+		if cmp, err := comparison.OneWays.TotalAmount.Cmp(comparison.MultiCity.TotalAmount); err == nil && cmp < 0 {
+			comparison.Cheapest = comparison.OneWays
+		}
+	}
+	return comparison, nil
+}
+
+// Savings returns how much less the cheapest itinerary costs than the other,
+// and false if only one of them was found or they are in different currencies.
+func (c *ItineraryComparison) Savings() (currency.Amount, bool) {
+	if c.MultiCity == nil || c.OneWays == nil {
+		return currency.Amount{}, false
+	}
+	other := c.MultiCity
+	if c.Cheapest == c.MultiCity {
+		other = c.OneWays
+	}
+	savings, err := other.TotalAmount.Sub(c.Cheapest.TotalAmount)
+	if err != nil {
+		return currency.Amount{}, false
+	}
+	return savings, true
+}
+
+// cheapestOffer returns the cheapest offer for the input. Offers priced in a different
+// currency from the first offer are left out, as they can't be compared.
+func cheapestOffer(ctx context.Context, client OfferRequestCreator, limiter *rate.Limiter, input OfferRequestInput) (string, *Offer, error) {
+	if limiter != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			return "", nil, err
+		}
+	}
+	input.ReturnOffers = true
+	offerRequest, err := client.CreateOfferRequest(ctx, input)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var cheapest *Offer
+	for i := range offerRequest.Offers {
+		offer := &offerRequest.Offers[i]
+		if offer.TotalAmount().CurrencyCode() == "" {
+			continue
+		}
+		if cheapest == nil {
+			cheapest = offer
+			continue
+		}
+		if cmp, err := offer.TotalAmount().Cmp(cheapest.TotalAmount()); err == nil && cmp < 0 {
+			cheapest = offer
+		}
+	}
+	if cheapest == nil {
+		return offerRequest.ID, nil, ErrNoOffers
+	}
+	return offerRequest.ID, cheapest, nil
+}
+
+// normalizeCode upper cases an IATA code, resolving city names when the planner has a resolver.
+func (p *ItineraryPlanner) normalizeCode(code string, slice int, field string) (string, *Error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		err := itineraryError(sliceSource(slice, field), fmt.Sprintf("slice %d has no %s", slice+1, field))
+		return "", &err
+	}
+	if p.resolver != nil {
+		if city, ok := p.resolver.City(code); ok && city.IATACode != "" {
+			return strings.ToUpper(city.IATACode), nil
+		}
+	}
+
+	code = strings.ToUpper(code)
+	if !isIATACode(code) {
+		err := itineraryError(sliceSource(slice, field), fmt.Sprintf("slice %d %s %q is not a three letter IATA code", slice+1, field, code))
+		return "", &err
+	}
+	if p.resolver != nil && len(p.resolver.Airports(code)) == 0 {
+		err := itineraryError(sliceSource(slice, field), fmt.Sprintf("slice %d %s %s is not a known city or airport", slice+1, field, code))
+		return "", &err
+	}
+	return code, nil
+}
+
+func (p *ItineraryPlanner) cityCode(code string) string {
+	if p.resolver == nil {
+		return strings.ToUpper(code)
+	}
+	return p.resolver.CityCode(code)
+}
+
+func isIATACode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func sliceSource(i int, field string) *ErrorSource {
+	return &ErrorSource{Field: field, Pointer: fmt.Sprintf("/slices/%d/%s", i, field)}
+}
+
+func itineraryError(source *ErrorSource, message string) Error {
+	return Error{
+		Type:    ValidationError,
+		Title:   "Invalid itinerary",
+		Message: message,
+		Code:    InvalidDataParam,
+		Source:  source,
+	}
+}
@@ -0,0 +1,289 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+	"gopkg.in/h2non/gock.v1"
+)
+
+// fakePlannerClient prices offer requests by their routes, e.g. "LON-NYC,BOS-LON".
+type fakePlannerClient struct {
+	prices map[string]string
+	mu     sync.Mutex
+	routes []string
+}
+
+func (c *fakePlannerClient) CreateOfferRequest(ctx context.Context, input OfferRequestInput) (*OfferRequest, error) {
+	routes := make([]string, len(input.Slices))
+	for i, s := range input.Slices {
+		routes[i] = s.Origin + "-" + s.Destination
+	}
+	route := strings.Join(routes, ",")
+
+	c.mu.Lock()
+	c.routes = append(c.routes, route)
+	c.mu.Unlock()
+
+	price, ok := c.prices[route]
+	if !ok {
+		return nil, fmt.Errorf("no flights for %s", route)
+	}
+	if price == "" {
+		return &OfferRequest{ID: "orq_" + route}, nil
+	}
+	amount, currency, _ := strings.Cut(price, " ")
+	return &OfferRequest{
+		ID: "orq_" + route,
+		Offers: []Offer{
+			{ID: "off_dear_" + route, RawTotalAmount: "9999.00", RawTotalCurrency: currency},
+			{ID: "off_" + route, RawTotalAmount: amount, RawTotalCurrency: currency},
+		},
+	}, nil
+}
+
+func testPlannerInput() OfferRequestInput {
+	return OfferRequestInput{
+		Passengers: []OfferRequestPassenger{{Type: PassengerTypeAdult}},
+		Slices: []OfferRequestSlice{
+			{Origin: "london", Destination: "nyc", DepartureDate: testCalendarDate(10)},
+			{Origin: "jfk", Destination: "LHR", DepartureDate: testCalendarDate(14)},
+		},
+	}
+}
+
+func testPlanner(opts ...ItineraryPlannerOption) *ItineraryPlanner {
+	opts = append([]ItineraryPlannerOption{
+		WithCityResolver(testCityResolver()),
+		WithPlannerRateLimiter(rate.NewLimiter(rate.Inf, 1)),
+	}, opts...)
+	return NewItineraryPlanner(opts...)
+}
+
+func TestItineraryPlannerPlan(t *testing.T) {
+	a := assert.New(t)
+
+	input, err := testPlanner().Plan(testPlannerInput())
+	require.NoError(t, err)
+	a.Equal("LON", input.Slices[0].Origin)
+	a.Equal("NYC", input.Slices[0].Destination)
+	a.Equal("JFK", input.Slices[1].Origin)
+	a.Equal("LHR", input.Slices[1].Destination)
+	a.Equal("london", testPlannerInput().Slices[0].Origin)
+
+	// Without a resolver, codes are only upper cased.
+	input = testPlannerInput()
+	input.Slices[0].Origin = " lhr"
+	input, err = NewItineraryPlanner().Plan(input)
+	require.NoError(t, err)
+	a.Equal("LHR", input.Slices[0].Origin)
+	a.Equal("NYC", input.Slices[0].Destination)
+}
+
+func TestItineraryPlannerPlanErrors(t *testing.T) {
+	a := assert.New(t)
+
+	input := testPlannerInput()
+	input.Slices = append(input.Slices,
+		OfferRequestSlice{Origin: "LHR", Destination: "lgw", DepartureDate: testCalendarDate(15)},
+		OfferRequestSlice{Origin: "XXX", Destination: "BFS", DepartureDate: testCalendarDate(12)},
+		OfferRequestSlice{Origin: "BFS", Destination: "New York"},
+	)
+	_, err := testPlanner(WithMinimumStay(2)).Plan(input)
+
+	var derr *DuffelError
+	require.True(t, errors.As(err, &derr))
+	messages := make(map[string]string)
+	for _, e := range derr.Errors {
+		a.Equal(ValidationError, e.Type)
+		a.Equal(InvalidDataParam, e.Code)
+		messages[e.Source.Pointer] = e.Message
+	}
+	a.Equal(map[string]string{
+		"/slices/2/destination":    "slice 3 departs from and arrives in LON",
+		"/slices/2/departure_date": "slice 3 departs 1 day after slice 2, less than the minimum stay of 2 days",
+		"/slices/3/origin":         "slice 4 origin XXX is not a known city or airport",
+		"/slices/3/departure_date": "slice 4 departs on 2026-11-12, before slice 3 on 2026-11-15",
+		"/slices/4/departure_date": "slice 5 has no departure date",
+	}, messages)
+
+	_, err = NewItineraryPlanner().Plan(OfferRequestInput{Slices: []OfferRequestSlice{
+		{Origin: "L1", Destination: "", DepartureDate: testCalendarDate(10)},
+	}})
+	require.True(t, errors.As(err, &derr))
+	require.Len(t, derr.Errors, 2)
+	a.Equal(`slice 1 origin "L1" is not a three letter IATA code`, derr.Errors[0].Message)
+	a.Equal("slice 1 has no destination", derr.Errors[1].Message)
+
+	_, err = NewItineraryPlanner().Plan(OfferRequestInput{})
+	a.True(IsErrorCode(err, InvalidDataParam))
+}
+
+func TestItineraryPlannerKind(t *testing.T) {
+	a := assert.New(t)
+	p := testPlanner()
+
+	input, err := p.Plan(testPlannerInput())
+	require.NoError(t, err)
+	a.Equal(ItineraryKindOneWay, p.Kind(input.Slices[:1]))
+	a.Equal(ItineraryKindReturn, p.Kind(input.Slices))
+	a.Equal(ItineraryKindOpenJaw, NewItineraryPlanner().Kind(input.Slices))
+
+	input.Slices[1].Origin = "BOS"
+	a.Equal(ItineraryKindOpenJaw, p.Kind(input.Slices))
+	a.Equal(ItineraryKindMultiCity, p.Kind(append(input.Slices, OfferRequestSlice{})))
+}
+
+func TestItineraryPlannerCompare(t *testing.T) {
+	a := assert.New(t)
+
+	client := &fakePlannerClient{prices: map[string]string{
+		"LON-NYC,JFK-LHR": "700.00 GBP",
+		"LON-NYC":         "250.00 GBP",
+		"JFK-LHR":         "300.00 GBP",
+	}}
+	comparison, err := testPlanner().Compare(context.Background(), client, testPlannerInput())
+	require.NoError(t, err)
+	a.ElementsMatch([]string{"LON-NYC,JFK-LHR", "LON-NYC", "JFK-LHR"}, client.routes)
+
+	require.NotNil(t, comparison.MultiCity)
+	a.False(comparison.MultiCity.SeparateTickets)
+	a.Equal("700.00 GBP", comparison.MultiCity.TotalAmount.String())
+	a.Equal("off_LON-NYC,JFK-LHR", comparison.MultiCity.Offers[0].ID)
+
+	require.NotNil(t, comparison.OneWays)
+	a.True(comparison.OneWays.SeparateTickets)
+	a.Equal("550.00 GBP", comparison.OneWays.TotalAmount.String())
+	a.Equal([]string{"orq_LON-NYC", "orq_JFK-LHR"}, comparison.OneWays.OfferRequestIDs)
+	a.Equal("off_LON-NYC", comparison.OneWays.Offers[0].ID)
+	a.Equal("off_JFK-LHR", comparison.OneWays.Offers[1].ID)
+
+	a.Same(comparison.OneWays, comparison.Cheapest)
+	savings, ok := comparison.Savings()
+	a.True(ok)
+	a.Equal("150.00 GBP", savings.String())
+
+	// Ties and mixed currencies go to the single ticket.
+	client.prices["LON-NYC,JFK-LHR"] = "550.00 GBP"
+	comparison, err = testPlanner().Compare(context.Background(), client, testPlannerInput())
+	require.NoError(t, err)
+	a.Same(comparison.MultiCity, comparison.Cheapest)
+
+	client.prices["LON-NYC,JFK-LHR"] = "100.00 USD"
+	client.prices["JFK-LHR"] = "300.00 USD"
+	comparison, err = testPlanner().Compare(context.Background(), client, testPlannerInput())
+	require.NoError(t, err)
+	a.Nil(comparison.OneWays)
+	require.Error(t, comparison.OneWayErr)
+	a.Contains(comparison.OneWayErr.Error(), "combining one-way offers")
+	a.Same(comparison.MultiCity, comparison.Cheapest)
+	_, ok = comparison.Savings()
+	a.False(ok)
+}
+
+func TestItineraryPlannerCompareErrors(t *testing.T) {
+	a := assert.New(t)
+
+	client := &fakePlannerClient{prices: map[string]string{
+		"LON-NYC": "250.00 GBP",
+		"JFK-LHR": "",
+	}}
+	comparison, err := testPlanner().Compare(context.Background(), client, testPlannerInput())
+	a.EqualError(err, "no flights for LON-NYC,JFK-LHR")
+	a.True(errors.Is(comparison.OneWayErr, ErrNoOffers))
+	a.Nil(comparison.Cheapest)
+
+	client.prices["LON-NYC,JFK-LHR"] = "700.00 GBP"
+	comparison, err = testPlanner().Compare(context.Background(), client, testPlannerInput())
+	require.NoError(t, err)
+	a.Same(comparison.MultiCity, comparison.Cheapest)
+	a.EqualError(comparison.OneWayErr, "searching slice 2: duffel: no offers found")
+
+	input := testPlannerInput()
+	input.Slices = input.Slices[:1]
+	client.routes = nil
+	comparison, err = testPlanner().Compare(context.Background(), client, input)
+	require.NoError(t, err)
+	a.Equal([]string{"LON-NYC"}, client.routes)
+	a.Nil(comparison.OneWays)
+	a.Same(comparison.MultiCity, comparison.Cheapest)
+
+	client.routes = nil
+	input.Slices[0].Origin = "NYC"
+	_, err = testPlanner().Compare(context.Background(), client, input)
+	a.True(IsErrorType(err, ValidationError))
+	a.Empty(client.routes)
+}
+
+func TestItineraryPlannerCompareAPI(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+
+	gock.New("https://api.duffel.com").
+		Post("/air/offer_requests").
+		MatchParam("return_offers", "true").
+		Times(3).
+		Reply(200).
+		SetHeader(RequestIDHeader, "FvxRwfFNBG8TSt0AAAAB").
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/200-get-offer-request.json")
+
+	// Concurrent requests made with the API share its rate limiter and request ID,
+	// which go test -race checks.
+	client := New("duffel_test_123")
+	comparison, err := NewItineraryPlanner(WithCityResolver(testCityResolver())).Compare(context.Background(), client, testPlannerInput())
+	require.NoError(t, err)
+	a.True(gock.IsDone())
+	a.Same(comparison.MultiCity, comparison.Cheapest)
+	a.Equal("orq_0000AEtEexyvXbB0OhB5jk", comparison.MultiCity.OfferRequestIDs[0])
+	a.Len(comparison.OneWays.Offers, 2)
+	id, _ := client.LastRequestID()
+	a.Equal("FvxRwfFNBG8TSt0AAAAB", id)
+}
+
+func TestItineraryPlannerCompareConcurrency(t *testing.T) {
+	a := assert.New(t)
+
+	var inFlight, maxInFlight int32
+	client := offerRequestCreatorFunc(func(ctx context.Context, input OfferRequestInput) (*OfferRequest, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		// Offers in another currency are left out rather than compared by currency code.
+		return &OfferRequest{Offers: []Offer{
+			{ID: "off_gbp", RawTotalAmount: "300.00", RawTotalCurrency: "GBP"},
+			{ID: "off_eur", RawTotalAmount: "10.00", RawTotalCurrency: "EUR"},
+			{ID: "off_gbp_cheaper", RawTotalAmount: "250.00", RawTotalCurrency: "GBP"},
+		}}, nil
+	})
+
+	comparison, err := testPlanner(WithPlannerConcurrency(1)).Compare(context.Background(), client, testPlannerInput())
+	require.NoError(t, err)
+	a.EqualValues(1, maxInFlight)
+	a.Equal("off_gbp_cheaper", comparison.MultiCity.Offers[0].ID)
+	a.Equal("500.00 GBP", comparison.OneWays.TotalAmount.String())
+	a.Same(comparison.MultiCity, comparison.Cheapest)
+}
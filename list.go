@@ -4,6 +4,8 @@
 
 package duffel
 
+import "net/url"
+
 // ListContainer is a general interface for which all list object structs
 // should comply. They achieve this by embedding a ListMeta struct and
 // inheriting its implementation of this interface.
@@ -22,6 +24,10 @@ type List[T any] struct {
 
 	// Duffel Request ID
 	lastRequestID string `json:"-" url:"-"`
+
+	// stream decodes the items of the page one at a time when streaming decoding
+	// is enabled, in which case items is empty.
+	stream *streamDecoder[T]
 }
 
 func (l *List[T]) GetItems() []*T {
@@ -53,7 +59,7 @@ type ListMeta struct {
 	After string `json:"after,omitempty" url:"after,omitempty"`
 
 	// Before is a string that contains the token for the previous page of results
-	Before string `json:"before,omitempty" url:"-"`
+	Before string `json:"before,omitempty" url:"before,omitempty"`
 
 	// Limit is a number that indicates the maximum number of items to return
 	Limit int `json:"limit,omitempty" url:"limit,omitempty"`
@@ -63,9 +69,44 @@ func (l *ListMeta) HasMore() bool {
 	return l.After != ""
 }
 
+// HasPrevious returns true if there is a page before the current one.
+func (l *ListMeta) HasPrevious() bool {
+	return l.Before != ""
+}
+
 // GetListMeta returns a ListMeta struct (itself). It exists because any
 // structs that embed ListMeta will inherit it, and thus implement the
 // ListContainer interface.
 func (l *ListMeta) GetListMeta() *ListMeta {
 	return l
 }
+
+// ListOptions sets the page size of a list and the position it starts from.
+// Every list params struct embeds it, so a cursor saved with Iter.Cursor()
+// can be passed back to resume a list.
+type ListOptions struct {
+	// Limit is the maximum number of items to return per page, between 1 and 200.
+	// Defaults to 50.
+	Limit int `json:"limit,omitempty" url:"-"`
+
+	// After starts the list at the page following this cursor.
+	After string `json:"after,omitempty" url:"-"`
+
+	// Before starts the list at the page preceding this cursor.
+	Before string `json:"before,omitempty" url:"-"`
+}
+
+// listOptions is implemented by list params that embed ListOptions.
+type listOptions interface {
+	getListOptions() ListOptions
+}
+
+func (o ListOptions) getListOptions() ListOptions {
+	return o
+}
+
+// Encode implements the ParamEncoder interface. ListOptions are not encoded
+// as query params directly, they set the pagination of the first request.
+func (o ListOptions) Encode(q url.Values) error {
+	return nil
+}
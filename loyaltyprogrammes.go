@@ -0,0 +1,193 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type (
+	LoyaltyProgrammesClient interface {
+		ListLoyaltyProgrammes(ctx context.Context, params ...ListLoyaltyProgrammesParams) *Iter[LoyaltyProgramme]
+		GetLoyaltyProgramme(ctx context.Context, id string) (*LoyaltyProgramme, error)
+	}
+
+	ListLoyaltyProgrammesParams struct {
+		ListOptions
+	}
+
+	// LoyaltyProgramme is a frequent flyer programme run by an airline.
+	LoyaltyProgramme struct {
+		ID       string   `json:"id"`
+		Name     string   `json:"name"`
+		Alliance Alliance `json:"alliance,omitempty"`
+		LogoURL  string   `json:"logo_url,omitempty"`
+
+		// The airline that owns the programme. Passenger loyalty accounts
+		// reference the programme by this airline's IATA code.
+		OwnerAirline Airline `json:"owner_airline"`
+	}
+
+	Alliance string
+
+	// LoyaltyProgrammeValidator checks passenger loyalty programme accounts
+	// against the programmes accepted for an offer, so that bad cards are
+	// caught before Duffel rejects the order with InvalidLoyaltyCard.
+	LoyaltyProgrammeValidator struct {
+		programmes map[string]*LoyaltyProgramme
+	}
+)
+
+const (
+	AllianceOneworld     Alliance = "oneworld"
+	AllianceStarAlliance Alliance = "star_alliance"
+	AllianceSkyTeam      Alliance = "skyteam"
+)
+
+func (a Alliance) String() string {
+	return string(a)
+}
+
+func (a *API) ListLoyaltyProgrammes(ctx context.Context, params ...ListLoyaltyProgrammesParams) *Iter[LoyaltyProgramme] {
+	return newRequestWithAPI[ListLoyaltyProgrammesParams, LoyaltyProgramme](a).
+		Get("/air/loyalty_programmes").
+		WithParams(normalizeParams(params)...).
+		Iter(ctx)
+}
+
+func (a *API) GetLoyaltyProgramme(ctx context.Context, id string) (*LoyaltyProgramme, error) {
+	return newRequestWithAPI[EmptyPayload, LoyaltyProgramme](a).
+		Getf("/air/loyalty_programmes/%s", id).
+		Single(ctx)
+}
+
+// NewLoyaltyProgrammeValidator loads all loyalty programmes from the client
+// and returns a validator backed by them.
+func NewLoyaltyProgrammeValidator(ctx context.Context, client LoyaltyProgrammesClient) (*LoyaltyProgrammeValidator, error) {
+	programmes, err := Collect(client.ListLoyaltyProgrammes(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return NewLoyaltyProgrammeValidatorFromList(programmes), nil
+}
+
+// NewLoyaltyProgrammeValidatorFromList returns a validator backed by an already loaded
+// list of loyalty programmes.
+func NewLoyaltyProgrammeValidatorFromList(programmes []*LoyaltyProgramme) *LoyaltyProgrammeValidator {
+	v := &LoyaltyProgrammeValidator{
+		programmes: make(map[string]*LoyaltyProgramme, len(programmes)),
+	}
+	for _, p := range programmes {
+		if p == nil || p.OwnerAirline.IATACode == "" {
+			continue
+		}
+		v.programmes[strings.ToUpper(p.OwnerAirline.IATACode)] = p
+	}
+	return v
+}
+
+// Programme returns the loyalty programme owned by the airline with the given IATA code.
+func (v *LoyaltyProgrammeValidator) Programme(airlineIATACode string) (*LoyaltyProgramme, bool) {
+	p, ok := v.programmes[strings.ToUpper(airlineIATACode)]
+	return p, ok
+}
+
+// Accepts reports whether the offer's owner accepts accounts from the programme
+// run by the airline with the given IATA code.
+//
+// Accounts are never accepted for an airline without a loyalty programme. Otherwise an
+// account is accepted when it belongs to the offer owner's own programme, or when the
+// offer lists the airline in SupportedLoyaltyProgrammes. If the offer does not list
+// any supported programmes the result is PermissionUnknown.
+func (v *LoyaltyProgrammeValidator) Accepts(offer *Offer, airlineIATACode string) Permission {
+	code := strings.ToUpper(airlineIATACode)
+	if !v.hasProgramme(code) {
+		return PermissionNotAllowed
+	}
+	if code == strings.ToUpper(offer.Owner.IATACode) {
+		return PermissionAllowed
+	}
+	if len(offer.SupportedLoyaltyProgrammes) == 0 {
+		return PermissionUnknown
+	}
+
+	for _, supported := range offer.SupportedLoyaltyProgrammes {
+		if strings.EqualFold(supported, code) {
+			return PermissionAllowed
+		}
+	}
+	return PermissionNotAllowed
+}
+
+// ValidateAccounts checks a single passenger's loyalty programme accounts against the offer.
+func (v *LoyaltyProgrammeValidator) ValidateAccounts(offer *Offer, passengerID string, accounts []LoyaltyProgrammeAccount) error {
+	errs := v.validateAccounts(offer, passengerID, accounts)
+	if len(errs) == 0 {
+		return nil
+	}
+	return &DuffelError{Errors: errs}
+}
+
+// ValidateOrder checks the loyalty programme accounts of every passenger on
+// the order input against the offer being booked. Call it before CreateOrder.
+func (v *LoyaltyProgrammeValidator) ValidateOrder(offer *Offer, input CreateOrderInput) error {
+	var errs []Error
+	for _, p := range input.Passengers {
+		errs = append(errs, v.validateAccounts(offer, p.ID, p.LoyaltyProgrammeAccounts)...)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &DuffelError{Errors: errs}
+}
+
+// ValidatePassengerUpdate checks the loyalty programme accounts of an offer passenger update.
+// Call it before UpdateOfferPassenger.
+func (v *LoyaltyProgrammeValidator) ValidatePassengerUpdate(offer *Offer, passengerID string, input PassengerUpdateInput) error {
+	return v.ValidateAccounts(offer, passengerID, input.LoyaltyProgrammeAccounts)
+}
+
+func (v *LoyaltyProgrammeValidator) validateAccounts(offer *Offer, passengerID string, accounts []LoyaltyProgrammeAccount) []Error {
+	var errs []Error
+	seen := map[string]bool{}
+
+	for _, account := range accounts {
+		code := strings.ToUpper(account.AirlineIATACode)
+
+		switch {
+		case code == "":
+			errs = append(errs, loyaltyCardError(fmt.Sprintf("passenger %s: loyalty programme account is missing an airline IATA code", passengerID)))
+		case strings.TrimSpace(account.AccountNumber) == "":
+			errs = append(errs, loyaltyCardError(fmt.Sprintf("passenger %s: loyalty programme account for %s is missing an account number", passengerID, code)))
+		case seen[code]:
+			errs = append(errs, loyaltyCardError(fmt.Sprintf("passenger %s: more than one loyalty programme account for %s", passengerID, code)))
+		case !v.hasProgramme(code):
+			errs = append(errs, loyaltyCardError(fmt.Sprintf("passenger %s: %s does not have a loyalty programme", passengerID, code)))
+		case v.Accepts(offer, code) == PermissionNotAllowed:
+			errs = append(errs, loyaltyCardError(fmt.Sprintf("passenger %s: %s does not accept loyalty programme accounts from %s", passengerID, offer.Owner.IATACode, code)))
+		}
+		seen[code] = true
+	}
+
+	return errs
+}
+
+func (v *LoyaltyProgrammeValidator) hasProgramme(airlineIATACode string) bool {
+	_, ok := v.Programme(airlineIATACode)
+	return ok
+}
+
+func loyaltyCardError(message string) Error {
+	return Error{
+		Type:    ValidationError,
+		Title:   "Invalid loyalty card",
+		Message: message,
+		Code:    InvalidLoyaltyCard,
+	}
+}
+
+var _ LoyaltyProgrammesClient = (*API)(nil)
@@ -0,0 +1,128 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestListLoyaltyProgrammes(t *testing.T) {
+	defer gock.Off()
+
+	a := assert.New(t)
+	gock.New("https://api.duffel.com").
+		Get("/air/loyalty_programmes").
+		Reply(200).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/200-list-loyalty-programmes.json")
+
+	ctx := context.TODO()
+
+	client := New("duffel_test_123")
+	programmes, err := Collect(client.ListLoyaltyProgrammes(ctx))
+	a.NoError(err)
+	a.Len(programmes, 3)
+
+	a.Equal("lyp_00009UhD4ongolulWd91Ky", programmes[0].ID)
+	a.Equal("Executive Club", programmes[0].Name)
+	a.Equal(AllianceOneworld, programmes[0].Alliance)
+	a.Equal("BA", programmes[0].OwnerAirline.IATACode)
+}
+
+func TestGetLoyaltyProgrammeByID(t *testing.T) {
+	defer gock.Off()
+
+	a := assert.New(t)
+	gock.New("https://api.duffel.com").
+		Get("/air/loyalty_programmes/lyp_00009UhD4ongolulWd91Ky").
+		Reply(200).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/200-get-loyalty-programme.json")
+
+	ctx := context.TODO()
+
+	client := New("duffel_test_123")
+	programme, err := client.GetLoyaltyProgramme(ctx, "lyp_00009UhD4ongolulWd91Ky")
+	a.NoError(err)
+	a.NotNil(programme)
+	a.Equal("Executive Club", programme.Name)
+	a.Equal("British Airways", programme.OwnerAirline.Name)
+}
+
+func TestLoyaltyProgrammeValidator(t *testing.T) {
+	a := assert.New(t)
+
+	v := NewLoyaltyProgrammeValidatorFromList([]*LoyaltyProgramme{
+		{ID: "lyp_1", Alliance: AllianceOneworld, OwnerAirline: Airline{IATACode: "BA"}},
+		{ID: "lyp_2", Alliance: AllianceOneworld, OwnerAirline: Airline{IATACode: "AA"}},
+		{ID: "lyp_3", Alliance: AllianceStarAlliance, OwnerAirline: Airline{IATACode: "LH"}},
+	})
+	offer := &Offer{Owner: Airline{IATACode: "BA"}}
+
+	// Without a list of supported programmes only the owner's own is known to be accepted.
+	a.Equal(PermissionAllowed, v.Accepts(offer, "BA"))
+	a.Equal(PermissionUnknown, v.Accepts(offer, "aa"))
+	a.Equal(PermissionUnknown, v.Accepts(offer, "LH"))
+	a.NoError(v.ValidatePassengerUpdate(offer, "pas_1", PassengerUpdateInput{
+		LoyaltyProgrammeAccounts: []LoyaltyProgrammeAccount{
+			{AirlineIATACode: "LH", AccountNumber: "LH1234567"},
+		},
+	}))
+
+	// Airlines without a loyalty programme are rejected either way.
+	a.Equal(PermissionNotAllowed, v.Accepts(offer, "ZZ"))
+	err := v.ValidatePassengerUpdate(offer, "pas_1", PassengerUpdateInput{
+		LoyaltyProgrammeAccounts: []LoyaltyProgrammeAccount{
+			{AirlineIATACode: "ZZ", AccountNumber: "ZZ1234567"},
+		},
+	})
+	a.True(IsErrorCode(err, InvalidLoyaltyCard))
+	a.EqualError(err, "duffel: passenger pas_1: ZZ does not have a loyalty programme")
+
+	offer.SupportedLoyaltyProgrammes = []string{"BA", "AA"}
+	a.Equal(PermissionAllowed, v.Accepts(offer, "aa"))
+	a.Equal(PermissionNotAllowed, v.Accepts(offer, "LH"))
+
+	err = v.ValidateOrder(offer, CreateOrderInput{
+		Passengers: []OrderPassenger{
+			{
+				ID: "pas_1",
+				LoyaltyProgrammeAccounts: []LoyaltyProgrammeAccount{
+					{AirlineIATACode: "AA", AccountNumber: "AA1234567"},
+					{AirlineIATACode: "LH", AccountNumber: "LH1234567"},
+				},
+			},
+			{
+				ID: "pas_2",
+				LoyaltyProgrammeAccounts: []LoyaltyProgrammeAccount{
+					{AirlineIATACode: "BA"},
+				},
+			},
+		},
+	})
+	a.Error(err)
+	a.True(IsErrorCode(err, InvalidLoyaltyCard))
+	a.Len(err.(*DuffelError).Errors, 2)
+	a.Equal("passenger pas_1: BA does not accept loyalty programme accounts from LH", err.(*DuffelError).Errors[0].Message)
+
+	offer.SupportedLoyaltyProgrammes = []string{"BA", "LH"}
+	a.NoError(v.ValidatePassengerUpdate(offer, "pas_1", PassengerUpdateInput{
+		LoyaltyProgrammeAccounts: []LoyaltyProgrammeAccount{
+			{AirlineIATACode: "LH", AccountNumber: "LH1234567"},
+		},
+	}))
+	a.Equal(PermissionNotAllowed, v.Accepts(offer, "AA"))
+}
@@ -0,0 +1,181 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/bojanz/currency"
+)
+
+type (
+	// ItineraryFamily is a set of offers for the same flights,
+	// such as different fare brands of the same itinerary.
+	ItineraryFamily struct {
+		// Key identifies the flights shared by every offer in the family.
+		Key string
+		// Offers are every offer for the itinerary, cheapest first.
+		Offers []*Offer
+		// Options are the cheapest offer of each fare brand, cheapest first,
+		// suitable for showing as upsell tiers.
+		Options []*FareOption
+	}
+
+	// FareOption is a fare brand available for an itinerary.
+	FareOption struct {
+		Offer *Offer
+		// FareBrandNames are the fare brands of each slice, e.g. "Basic" or "Flex".
+		FareBrandNames  []string
+		Conditions      Conditions
+		SliceConditions []SliceConditions
+		// CheckedBags and CarryOnBags are the bags included for every passenger on every segment.
+		CheckedBags int
+		CarryOnBags int
+	}
+)
+
+// ItineraryKey returns a key identifying the flights of an offer, made up of the
+// marketing and operating carriers, flight numbers and departure times of each
+// segment. Offers with the same key are for the same flights, even if they come
+// from different suppliers or searches.
+func ItineraryKey(offer *Offer) string {
+	keys := make([]string, len(offer.Slices))
+	for i := range offer.Slices {
+		keys[i] = sliceKey(&offer.Slices[i])
+	}
+	return strings.Join(keys, "/")
+}
+
+// sliceKey identifies the flights of a single slice, as part of an ItineraryKey.
+func sliceKey(slice *Slice) string {
+	var b strings.Builder
+	for j, segment := range slice.Segments {
+		if j > 0 {
+			b.WriteString("|")
+		}
+		b.WriteString(strings.ToUpper(segment.MarketingCarrier.IATACode))
+		b.WriteString(segment.MarketingCarrierFlightNumber)
+		b.WriteString(":")
+		b.WriteString(strings.ToUpper(segment.OperatingCarrier.IATACode))
+		b.WriteString(segment.OperatingCarrierFlightNumber)
+		b.WriteString("@")
+		b.WriteString(segment.RawDepartingAt)
+	}
+	return b.String()
+}
+
+// GroupOffersByItinerary groups offers for the same flights into families,
+// ordered by their cheapest offer.
+func GroupOffersByItinerary(offers []*Offer) []*ItineraryFamily {
+	families := make([]*ItineraryFamily, 0)
+	byKey := make(map[string]*ItineraryFamily)
+	for _, offer := range offers {
+		key := ItineraryKey(offer)
+		family, ok := byKey[key]
+		if !ok {
+			family = &ItineraryFamily{Key: key}
+			byKey[key] = family
+			families = append(families, family)
+		}
+		family.Offers = append(family.Offers, offer)
+	}
+
+	for _, family := range families {
+		SortOffers(family.Offers, ByTotalAmount)
+
+		seen := make(map[string]bool)
+		for _, offer := range family.Offers {
+			option := newFareOption(offer)
+			brand := strings.Join(option.FareBrandNames, "/")
+			if seen[brand] {
+				continue
+			}
+			seen[brand] = true
+			family.Options = append(family.Options, option)
+		}
+	}
+
+	sort.SliceStable(families, func(i, j int) bool {
+		return ByTotalAmount(families[i].Offers[0], families[j].Offers[0]) < 0
+	})
+	return families
+}
+
+// DedupeOffers returns the cheapest offer for each itinerary and fare brand,
+// in the order they first appear.
+func DedupeOffers(offers []*Offer) []*Offer {
+	index := make(map[string]int)
+	deduped := make([]*Offer, 0, len(offers))
+	for _, offer := range offers {
+		key := ItineraryKey(offer) + "#" + strings.Join(fareBrandNames(offer), "/")
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(deduped)
+			deduped = append(deduped, offer)
+			continue
+		}
+		if ByTotalAmount(offer, deduped[i]) < 0 {
+			deduped[i] = offer
+		}
+	}
+	return deduped
+}
+
+// Cheapest returns the cheapest offer in the family.
+func (f *ItineraryFamily) Cheapest() *Offer {
+	if len(f.Offers) == 0 {
+		return nil
+	}
+	return f.Offers[0]
+}
+
+// TotalAmount returns the total amount of the option's offer.
+func (o *FareOption) TotalAmount() currency.Amount {
+	return o.Offer.TotalAmount()
+}
+
+func newFareOption(offer *Offer) *FareOption {
+	option := &FareOption{
+		Offer:           offer,
+		FareBrandNames:  fareBrandNames(offer),
+		Conditions:      offer.Conditions,
+		SliceConditions: make([]SliceConditions, len(offer.Slices)),
+	}
+	for i, slice := range offer.Slices {
+		option.SliceConditions[i] = slice.Conditions
+	}
+	option.CheckedBags, option.CarryOnBags = includedBags(offer)
+	return option
+}
+
+func fareBrandNames(offer *Offer) []string {
+	names := make([]string, len(offer.Slices))
+	for i, slice := range offer.Slices {
+		names[i] = slice.FareBrandName
+	}
+	return names
+}
+
+// includedBags returns the fewest checked and carry-on bags included
+// for any passenger on any segment of the offer.
+func includedBags(offer *Offer) (checked, carryOn int) {
+	first := true
+	for _, slice := range offer.Slices {
+		for _, segment := range slice.Segments {
+			for _, p := range segment.Passengers {
+				c, o := countBags(p.Baggages)
+				if first || c < checked {
+					checked = c
+				}
+				if first || o < carryOn {
+					carryOn = o
+				}
+				first = false
+			}
+		}
+	}
+	return checked, carryOn
+}
@@ -0,0 +1,69 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testFamilyOffer(id, amount, flightNumber, brand string, checked int) *Offer {
+	return &Offer{
+		ID:               id,
+		RawTotalAmount:   amount,
+		RawTotalCurrency: "GBP",
+		Conditions:       Conditions{ChangeBeforeDeparture: &ChangeCondition{Allowed: brand != "Basic"}},
+		Slices: []Slice{{
+			FareBrandName: brand,
+			Segments: []Flight{{
+				MarketingCarrier:             Airline{IATACode: "BA"},
+				MarketingCarrierFlightNumber: flightNumber,
+				OperatingCarrier:             Airline{IATACode: "BA"},
+				OperatingCarrierFlightNumber: flightNumber,
+				RawDepartingAt:               "2024-03-10T18:00:00",
+				Passengers: []SegmentPassenger{{Baggages: []Baggage{
+					{Type: "checked", Quantity: checked},
+					{Type: "carry_on", Quantity: 1},
+				}}},
+			}},
+		}},
+	}
+}
+
+func TestGroupOffersByItinerary(t *testing.T) {
+	a := assert.New(t)
+
+	offers := []*Offer{
+		testFamilyOffer("off_flex", "600.00", "117", "Flex", 2),
+		testFamilyOffer("off_basic", "300.00", "117", "Basic", 0),
+		testFamilyOffer("off_other", "250.00", "175", "Basic", 0),
+		testFamilyOffer("off_basic_dupe", "320.00", "117", "Basic", 0),
+		testFamilyOffer("off_standard", "400.00", "117", "Standard", 1),
+	}
+
+	a.Equal("BA117:BA117@2024-03-10T18:00:00", ItineraryKey(offers[0]))
+
+	families := GroupOffersByItinerary(offers)
+	a.Len(families, 2)
+	a.Equal("off_other", families[0].Cheapest().ID)
+
+	ba117 := families[1]
+	a.Len(ba117.Offers, 4)
+	a.Len(ba117.Options, 3)
+	a.Equal("off_basic", ba117.Options[0].Offer.ID)
+	a.Equal([]string{"Basic"}, ba117.Options[0].FareBrandNames)
+	a.Equal(0, ba117.Options[0].CheckedBags)
+	a.Equal(1, ba117.Options[0].CarryOnBags)
+	a.False(ba117.Options[0].Conditions.ChangeBeforeDeparture.Allowed)
+	a.Equal("Standard", ba117.Options[1].FareBrandNames[0])
+	a.Equal("off_flex", ba117.Options[2].Offer.ID)
+	a.Equal(2, ba117.Options[2].CheckedBags)
+	a.Equal("600.00", ba117.Options[2].TotalAmount().Number())
+
+	deduped := DedupeOffers(offers)
+	a.Len(deduped, 4)
+	a.Equal("off_basic", deduped[1].ID)
+}
@@ -0,0 +1,198 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/bojanz/currency"
+)
+
+type (
+	// OfferDiff describes how an offer changed between two fetches.
+	OfferDiff struct {
+		Previous *Offer
+		Current  *Offer
+
+		// PriceChanged is true if the offer's total amount changed.
+		PriceChanged  bool
+		PreviousTotal currency.Amount
+		CurrentTotal  currency.Amount
+
+		// RemovedServices are services that are no longer available.
+		RemovedServices []AvailableService
+		// RepricedServices are services that are still available at a different price.
+		RepricedServices []ServicePriceChange
+
+		// ConditionsChanged is true if the offer's refund or change conditions changed.
+		ConditionsChanged bool
+		// SliceChanges are the slices whose flights, fare brand or conditions changed.
+		SliceChanges []OfferSliceChange
+	}
+
+	// ServicePriceChange is a service whose price changed.
+	ServicePriceChange struct {
+		Previous AvailableService
+		Current  AvailableService
+	}
+
+	// OfferSliceChange describes how a slice of an offer changed.
+	OfferSliceChange struct {
+		// Index is the position of the slice in the offer.
+		Index int
+		// FlightsChanged is true if the slice's flights or their times changed,
+		// or the slice was added or removed.
+		FlightsChanged    bool
+		FareBrandChanged  bool
+		ConditionsChanged bool
+	}
+
+	// OfferExpiryEvent is sent by WatchOfferExpiry as an offer is about to expire and when it has.
+	OfferExpiryEvent struct {
+		Offer     *Offer
+		ExpiresAt time.Time
+		// Expired is false for the warning sent before the offer expires, and true once it has.
+		Expired bool
+		// Remaining is how long the offer had left when the event was sent.
+		Remaining time.Duration
+	}
+)
+
+// RefreshOffer fetches the offer again, along with its available services, and
+// returns the latest offer and how it differs from the one given. Call it before
+// booking to ask the customer to confirm any changes, rather than failing at CreateOrder.
+func (a *API) RefreshOffer(ctx context.Context, offer *Offer) (*Offer, *OfferDiff, error) {
+	current, err := a.GetOffer(ctx, offer.ID, GetOfferParams{ReturnAvailableServices: true})
+	if err != nil {
+		return nil, nil, err
+	}
+	return current, DiffOffers(offer, current), nil
+}
+
+// DiffOffers compares two fetches of the same offer.
+func DiffOffers(previous, current *Offer) *OfferDiff {
+	diff := &OfferDiff{
+		Previous:      previous,
+		Current:       current,
+		PreviousTotal: previous.TotalAmount(),
+		CurrentTotal:  current.TotalAmount(),
+	}
+	diff.PriceChanged = !diff.PreviousTotal.Equal(diff.CurrentTotal)
+	diff.ConditionsChanged = !reflect.DeepEqual(previous.Conditions, current.Conditions)
+
+	services := make(map[string]*AvailableService, len(current.AvailableServices))
+	for i := range current.AvailableServices {
+		services[current.AvailableServices[i].ID] = &current.AvailableServices[i]
+	}
+	for _, s := range previous.AvailableServices {
+		c, ok := services[s.ID]
+		switch {
+		case !ok:
+			diff.RemovedServices = append(diff.RemovedServices, s)
+		case s.RawTotalAmount != c.RawTotalAmount || s.RawTotalCurrency != c.RawTotalCurrency:
+			diff.RepricedServices = append(diff.RepricedServices, ServicePriceChange{Previous: s, Current: *c})
+		}
+	}
+
+	slices := len(previous.Slices)
+	if len(current.Slices) > slices {
+		slices = len(current.Slices)
+	}
+	for i := 0; i < slices; i++ {
+		if i >= len(previous.Slices) || i >= len(current.Slices) {
+			diff.SliceChanges = append(diff.SliceChanges, OfferSliceChange{Index: i, FlightsChanged: true})
+			continue
+		}
+		p, c := &previous.Slices[i], &current.Slices[i]
+		change := OfferSliceChange{
+			Index:             i,
+			FlightsChanged:    sliceKey(p) != sliceKey(c),
+			FareBrandChanged:  p.FareBrandName != c.FareBrandName,
+			ConditionsChanged: !reflect.DeepEqual(p.Conditions, c.Conditions),
+		}
+		if change.FlightsChanged || change.FareBrandChanged || change.ConditionsChanged {
+			diff.SliceChanges = append(diff.SliceChanges, change)
+		}
+	}
+	return diff
+}
+
+// Changed reports whether anything the customer agreed to has changed.
+func (d *OfferDiff) Changed() bool {
+	return d.PriceChanged || d.ConditionsChanged || len(d.RemovedServices) > 0 ||
+		len(d.RepricedServices) > 0 || len(d.SliceChanges) > 0
+}
+
+// PriceDifference returns how much more the offer costs now, which is negative if it is cheaper.
+func (d *OfferDiff) PriceDifference() (currency.Amount, error) {
+	return d.CurrentTotal.Sub(d.PreviousTotal)
+}
+
+// ServiceRemoved reports whether the service with the given ID is no longer available.
+func (d *OfferDiff) ServiceRemoved(id string) bool {
+	for _, s := range d.RemovedServices {
+		if s.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// WatchOfferExpiry sends an event on the returned channel warnBefore the offer
+// expires, and another once it has expired, then closes the channel. If the offer
+// is already within warnBefore of expiring, the warning is sent straight away.
+// The channel is closed early if ctx is cancelled, and straight away if the offer
+// has no expiry time.
+//
+//	for event := range duffel.WatchOfferExpiry(ctx, offer, 2*time.Minute) {
+//		if !event.Expired {
+//			// ask the customer to confirm soon, or refresh the offer
+//		}
+//	}
+func WatchOfferExpiry(ctx context.Context, offer *Offer, warnBefore time.Duration) <-chan OfferExpiryEvent {
+	// Buffered so that the watcher never blocks on a reader that has gone away.
+	events := make(chan OfferExpiryEvent, 2)
+	expiresAt := offer.ExpiresAt
+	if expiresAt.IsZero() {
+		close(events)
+		return events
+	}
+
+	go func() {
+		defer close(events)
+
+		warnAt := expiresAt.Add(-warnBefore)
+		if time.Now().Before(expiresAt) {
+			if !sleepUntil(ctx, warnAt) {
+				return
+			}
+			events <- OfferExpiryEvent{Offer: offer, ExpiresAt: expiresAt, Remaining: time.Until(expiresAt)}
+		}
+
+		if !sleepUntil(ctx, expiresAt) {
+			return
+		}
+		events <- OfferExpiryEvent{Offer: offer, ExpiresAt: expiresAt, Expired: true}
+	}()
+	return events
+}
+
+// sleepUntil waits until t, returning false if ctx is cancelled first.
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	wait := time.Until(t)
+	if wait <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
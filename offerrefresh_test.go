@@ -0,0 +1,139 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/segmentio/encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestRefreshOffer(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+
+	gock.New("https://api.duffel.com").
+		Get("/air/offers/off_00009htYpSCXrwaB9DnUm0").
+		MatchParam("return_available_services", "true").
+		Reply(200).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/200-offers-off_00009htYpSCXrwaB9DnUm0.json")
+
+	previous := &Offer{
+		ID:               "off_00009htYpSCXrwaB9DnUm0",
+		RawTotalAmount:   "40.00",
+		RawTotalCurrency: "GBP",
+		AvailableServices: []AvailableService{
+			{ID: "ase_00009UhD4ongolulWd9123", RawTotalAmount: "15.00", RawTotalCurrency: "GBP"},
+			{ID: "ase_removed", RawTotalAmount: "20.00", RawTotalCurrency: "GBP"},
+		},
+	}
+
+	client := New("duffel_test_123").(*API)
+	current, diff, err := client.RefreshOffer(context.TODO(), previous)
+	require.NoError(t, err)
+	a.Equal("45.00 GBP", current.TotalAmount().String())
+
+	a.True(diff.Changed())
+	a.True(diff.PriceChanged)
+	difference, err := diff.PriceDifference()
+	a.NoError(err)
+	a.Equal("5.00 GBP", difference.String())
+
+	a.Len(diff.RemovedServices, 1)
+	a.True(diff.ServiceRemoved("ase_removed"))
+	a.False(diff.ServiceRemoved("ase_00009UhD4ongolulWd9123"))
+	a.Empty(diff.RepricedServices)
+
+	a.True(diff.ConditionsChanged)
+	a.Equal([]OfferSliceChange{{Index: 0, FlightsChanged: true}}, diff.SliceChanges)
+}
+
+func TestDiffOffers(t *testing.T) {
+	a := assert.New(t)
+
+	offer := loadTestOffer(t)
+	a.False(DiffOffers(offer, loadTestOffer(t)).Changed())
+
+	changed := loadTestOffer(t)
+	changed.AvailableServices[0].RawTotalAmount = "18.00"
+	changed.Slices[0].FareBrandName = "Flex"
+	changed.Slices[0].Conditions.ChangeBeforeDeparture = &ChangeCondition{Allowed: true}
+
+	diff := DiffOffers(offer, changed)
+	a.True(diff.Changed())
+	a.False(diff.PriceChanged)
+	a.False(diff.ConditionsChanged)
+	require.Len(t, diff.RepricedServices, 1)
+	a.Equal("18.00", diff.RepricedServices[0].Current.RawTotalAmount)
+	a.Equal([]OfferSliceChange{{Index: 0, FareBrandChanged: true, ConditionsChanged: true}}, diff.SliceChanges)
+
+	changed = loadTestOffer(t)
+	changed.Slices[0].Segments[0].RawDepartingAt = "2020-06-13T18:00:00"
+	changed.Slices = append(changed.Slices, changed.Slices[0])
+	a.Equal([]OfferSliceChange{
+		{Index: 0, FlightsChanged: true},
+		{Index: 1, FlightsChanged: true},
+	}, DiffOffers(offer, changed).SliceChanges)
+}
+
+func TestWatchOfferExpiry(t *testing.T) {
+	a := assert.New(t)
+
+	offer := &Offer{ExpiresAt: time.Now().Add(100 * time.Millisecond)}
+	events := WatchOfferExpiry(context.Background(), offer, 60*time.Millisecond)
+
+	warning, ok := <-events
+	a.True(ok)
+	a.False(warning.Expired)
+	a.Same(offer, warning.Offer)
+	a.True(warning.Remaining > 0 && warning.Remaining <= 60*time.Millisecond)
+
+	expired, ok := <-events
+	a.True(ok)
+	a.True(expired.Expired)
+	a.False(time.Now().Before(offer.ExpiresAt))
+
+	_, ok = <-events
+	a.False(ok)
+
+	// An offer that has already expired only sends the expired event.
+	var received []OfferExpiryEvent
+	for event := range WatchOfferExpiry(context.Background(), &Offer{ExpiresAt: time.Now().Add(-time.Minute)}, time.Minute) {
+		received = append(received, event)
+	}
+	require.Len(t, received, 1)
+	a.True(received[0].Expired)
+
+	// Offers without an expiry time send nothing.
+	_, ok = <-WatchOfferExpiry(context.Background(), &Offer{}, time.Minute)
+	a.False(ok)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events = WatchOfferExpiry(ctx, &Offer{ExpiresAt: time.Now().Add(time.Hour)}, time.Minute)
+	cancel()
+	_, ok = <-events
+	a.False(ok)
+}
+
+func loadTestOffer(t *testing.T) *Offer {
+	data, err := os.ReadFile("fixtures/200-offers-off_00009htYpSCXrwaB9DnUm0.json")
+	require.NoError(t, err)
+
+	var resp struct {
+		Data *Offer `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(data, &resp))
+	return resp.Data
+}
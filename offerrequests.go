@@ -15,7 +15,18 @@ type (
 	OfferRequestClient interface {
 		CreateOfferRequest(ctx context.Context, requestInput OfferRequestInput) (*OfferRequest, error)
 		GetOfferRequest(ctx context.Context, id string) (*OfferRequest, error)
-		ListOfferRequests(ctx context.Context) *Iter[OfferRequest]
+		ListOfferRequests(ctx context.Context, params ...ListOfferRequestsParams) *Iter[OfferRequest]
+	}
+
+	// OfferRequestCreator creates offer requests. It is the part of the API used
+	// by searches that create many offer requests, such as SearchPriceCalendar
+	// and ItineraryPlanner.
+	OfferRequestCreator interface {
+		CreateOfferRequest(ctx context.Context, requestInput OfferRequestInput) (*OfferRequest, error)
+	}
+
+	ListOfferRequestsParams struct {
+		ListOptions
 	}
 
 	OfferRequestInput struct {
@@ -72,8 +83,11 @@ func (a *API) GetOfferRequest(ctx context.Context, id string) (*OfferRequest, er
 	return newRequestWithAPI[EmptyPayload, OfferRequest](a).Getf("/air/offer_requests/%s", id).Single(ctx)
 }
 
-func (a *API) ListOfferRequests(ctx context.Context) *Iter[OfferRequest] {
-	return newRequestWithAPI[EmptyPayload, OfferRequest](a).Get("/air/offer_requests").Iter(ctx)
+func (a *API) ListOfferRequests(ctx context.Context, params ...ListOfferRequestsParams) *Iter[OfferRequest] {
+	return newRequestWithAPI[ListOfferRequestsParams, OfferRequest](a).
+		Get("/air/offer_requests").
+		WithParams(normalizeParams(params)...).
+		Iter(ctx)
 }
 
 // Encode implements the ParamEncoder interface.
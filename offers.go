@@ -46,6 +46,9 @@ type (
 		PaymentRequirements                   OfferPaymentRequirement `json:"payment_requirements"`
 		AvailableServices                     []AvailableService      `json:"available_services"`
 		Conditions                            Conditions              `json:"conditions"`
+
+		// The IATA codes of the airlines whose loyalty programmes are supported by this offer.
+		SupportedLoyaltyProgrammes []string `json:"supported_loyalty_programmes,omitempty"`
 	}
 
 	AvailableService struct {
@@ -80,6 +83,7 @@ type (
 	ListOffersSortParam string
 
 	ListOffersParams struct {
+		ListOptions
 		Sort           ListOffersSortParam `url:"sort,omitempty"`
 		MaxConnections int                 `url:"max_connections,omitempty"`
 	}
@@ -168,13 +172,19 @@ func (o *Offer) TaxAmount() currency.Amount {
 	return amount
 }
 
-// Less will sort ascending by total amount
+// Less will sort ascending by total amount.
+// Offers in different currencies are ordered by currency code.
 func (o Offers) Less(i, j int) bool {
-	cmp, err := o[i].TotalAmount().Cmp(o[j].TotalAmount())
-	if err != nil {
-		return false
+	return compareAmounts(o[i].TotalAmount(), o[j].TotalAmount()) < 0
+}
+
+// Pointers returns pointers to the offers, for use with helpers such as OfferScorer.
+func (o Offers) Pointers() []*Offer {
+	offers := make([]*Offer, len(o))
+	for i := range o {
+		offers[i] = &o[i]
 	}
-	return cmp < 0
+	return offers
 }
 
 func (o Offers) Swap(i, j int) {
@@ -42,6 +42,17 @@ func WithHTTPClient(client *http.Client) Option {
 	}
 }
 
+// WithStreamingDecode decodes the items of list responses one at a time as the
+// iterator advances, instead of decoding every item on a page up front.
+// This reduces memory use when listing large pages, such as offers with available services.
+//
+// Iter.List().GetItems() is empty when streaming is enabled.
+func WithStreamingDecode() Option {
+	return func(c *Options) {
+		c.StreamingDecode = true
+	}
+}
+
 // WithDebug enables debug logging of requests and responses.
 // DO NOT USE IN PRODUCTION.
 func WithDebug() Option {
@@ -0,0 +1,290 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"fmt"
+
+	"github.com/bojanz/currency"
+)
+
+type (
+	// OrderBuilder builds the CreateOrderInput to book an offer, checking it against
+	// the offer so that mistakes are caught before spending a request on CreateOrder.
+	//
+	// The zero value is ready to use:
+	//
+	//	input, err := new(duffel.OrderBuilder).
+	//		FromOffer(offer).
+	//		Passengers(passengers...).
+	//		Service(bagServiceID, 1).
+	//		Pay(duffel.PaymentMethodBalance).
+	//		Build()
+	OrderBuilder struct {
+		offer      *Offer
+		orderType  OrderType
+		method     PaymentMethod
+		payment    *PaymentCreateInput
+		passengers []OrderPassenger
+		services   []ServiceCreateInput
+		metadata   Metadata
+
+		// seatPrices are the prices of seat services added with Seats,
+		// which aren't listed in the offer's available services.
+		seatPrices map[string]currency.Amount
+	}
+)
+
+// NewOrderBuilder returns a builder for the offer.
+func NewOrderBuilder(offer *Offer) *OrderBuilder {
+	return new(OrderBuilder).FromOffer(offer)
+}
+
+// FromOffer sets the offer to book. Passengers, services and payment are checked against it.
+func (b *OrderBuilder) FromOffer(offer *Offer) *OrderBuilder {
+	b.offer = offer
+	return b
+}
+
+// Passengers adds the details of passengers on the offer.
+func (b *OrderBuilder) Passengers(passengers ...OrderPassenger) *OrderBuilder {
+	b.passengers = append(b.passengers, passengers...)
+	return b
+}
+
+// Service adds quantity of one of the offer's available services.
+func (b *OrderBuilder) Service(id string, quantity int) *OrderBuilder {
+	b.services = append(b.services, ServiceCreateInput{ID: id, Quantity: quantity})
+	return b
+}
+
+// Seats adds the selected seats as services.
+func (b *OrderBuilder) Seats(selection *SeatSelection) *OrderBuilder {
+	if b.seatPrices == nil {
+		b.seatPrices = make(map[string]currency.Amount)
+	}
+	for _, selected := range selection.Selected() {
+		b.seatPrices[selected.Service.ID] = selected.Service.TotalAmount()
+	}
+	b.services = append(b.services, selection.Services()...)
+	return b
+}
+
+// Metadata sets the metadata of the order.
+func (b *OrderBuilder) Metadata(metadata Metadata) *OrderBuilder {
+	b.metadata = metadata
+	return b
+}
+
+// Hold books the offer without paying for it. Hold orders can't have payments or services.
+func (b *OrderBuilder) Hold() *OrderBuilder {
+	b.orderType = OrderTypeHold
+	b.method = ""
+	b.payment = nil
+	return b
+}
+
+// Pay books the offer and pays for it instantly with the given method. The payment
+// amount is the offer's total amount plus the price of its services.
+// Orders are paid from the balance unless Hold, Pay or Payment is called.
+func (b *OrderBuilder) Pay(method PaymentMethod) *OrderBuilder {
+	b.orderType = OrderTypeInstant
+	b.method = method
+	b.payment = nil
+	return b
+}
+
+// Payment books the offer and pays for it instantly with the given payment,
+// which must match the offer's total amount plus the price of its services.
+func (b *OrderBuilder) Payment(payment PaymentCreateInput) *OrderBuilder {
+	b.orderType = OrderTypeInstant
+	b.payment = &payment
+	return b
+}
+
+// TotalAmount returns the offer's total amount plus the price of the services added so far.
+func (b *OrderBuilder) TotalAmount() (currency.Amount, error) {
+	if b.offer == nil {
+		return currency.Amount{}, &DuffelError{Errors: []Error{orderError(InvalidDataParam, "an offer is required")}}
+	}
+	total, err := currency.NewAmount(b.offer.RawTotalAmount, b.offer.RawTotalCurrency)
+	if err != nil {
+		return currency.Amount{}, err
+	}
+	for _, s := range b.services {
+		price, ok := b.servicePrice(s)
+		if !ok {
+			continue
+		}
+		total, err = total.Add(price)
+		if err != nil {
+			return currency.Amount{}, err
+		}
+	}
+	return total, nil
+}
+
+// Validate checks the order against the offer, returning every problem found as a single error.
+func (b *OrderBuilder) Validate() error {
+	_, err := b.Build()
+	return err
+}
+
+// Build returns the input to CreateOrder, or a *DuffelError listing every rule the order breaks:
+//
+//   - the passengers' details are valid, as checked by ValidatePassengers
+//   - services are available on the offer, within their maximum quantity
+//   - instant orders have a single payment in the offer's currency for its total
+//     amount plus services
+//   - hold orders have no payments or services, and the offer doesn't require instant payment
+func (b *OrderBuilder) Build() (CreateOrderInput, error) {
+	if b.offer == nil {
+		return CreateOrderInput{}, &DuffelError{Errors: []Error{orderError(InvalidDataParam, "an offer is required")}}
+	}
+
+	orderType := b.orderType
+	if orderType == "" {
+		orderType = OrderTypeInstant
+	}
+	input := CreateOrderInput{
+		Type:           orderType,
+		Metadata:       b.metadata,
+		Passengers:     b.passengers,
+		SelectedOffers: []string{b.offer.ID},
+		Services:       b.services,
+	}
+
+	var errs []Error
+	errs = append(errs, validatePassengers(b.offer, b.passengers)...)
+	errs = append(errs, b.validateServices()...)
+
+	switch orderType {
+	case OrderTypeHold:
+		if b.offer.PaymentRequirements.RequiresInstantPayment {
+			errs = append(errs, orderError(InvalidDataParam, fmt.Sprintf("offer %s requires instant payment and can't be held", b.offer.ID)))
+		}
+		if len(b.services) > 0 {
+			errs = append(errs, orderError(InvalidDataParam, "hold orders can't include services"))
+		}
+	case OrderTypeInstant:
+		payment, err := b.buildPayment()
+		if err != nil {
+			errs = append(errs, err...)
+		} else {
+			input.Payments = []PaymentCreateInput{payment}
+		}
+	default:
+		errs = append(errs, orderError(InvalidDataParam, fmt.Sprintf("unknown order type %q", orderType)))
+	}
+
+	if len(errs) > 0 {
+		return CreateOrderInput{}, &DuffelError{Errors: errs}
+	}
+	return input, nil
+}
+
+func (b *OrderBuilder) validateServices() []Error {
+	var errs []Error
+	quantities := make(map[string]int, len(b.services))
+	for _, s := range b.services {
+		quantities[s.ID] += s.Quantity
+		if s.Quantity < 1 {
+			errs = append(errs, orderError(InvalidDataParam, fmt.Sprintf("service %s must have a quantity of at least 1", s.ID)))
+		}
+	}
+
+	for _, s := range b.services {
+		total, ok := quantities[s.ID]
+		if !ok {
+			continue
+		}
+		delete(quantities, s.ID)
+
+		if _, ok := b.seatPrices[s.ID]; ok {
+			if total > 1 {
+				errs = append(errs, orderError(InvalidDataParam, fmt.Sprintf("seat service %s can only be booked once", s.ID)))
+			}
+			continue
+		}
+		available := b.availableService(s.ID)
+		switch {
+		case available == nil:
+			errs = append(errs, orderError(AncillaryServiceNotAvailable, fmt.Sprintf("service %s is not available on offer %s", s.ID, b.offer.ID)))
+		case available.MaximumQuantity > 0 && total > available.MaximumQuantity:
+			errs = append(errs, orderError(InvalidDataParam, fmt.Sprintf("service %s can be booked at most %d times, not %d", s.ID, available.MaximumQuantity, total)))
+		}
+	}
+	return errs
+}
+
+func (b *OrderBuilder) buildPayment() (PaymentCreateInput, []Error) {
+	total, err := b.TotalAmount()
+	if err != nil {
+		return PaymentCreateInput{}, []Error{orderError(InvalidDataParam, fmt.Sprintf("offer %s has an invalid total amount: %s", b.offer.ID, err))}
+	}
+
+	if b.payment == nil {
+		method := b.method
+		if method == "" {
+			method = PaymentMethodBalance
+		}
+		return PaymentCreateInput{Amount: total.Number(), Currency: total.CurrencyCode(), Type: method}, nil
+	}
+
+	payment := *b.payment
+	if payment.Currency != total.CurrencyCode() {
+		return payment, []Error{orderError(InvalidDataParam, fmt.Sprintf("payment currency %s does not match offer currency %s", payment.Currency, total.CurrencyCode()))}
+	}
+	amount, err := currency.NewAmount(payment.Amount, payment.Currency)
+	if err != nil {
+		return payment, []Error{orderError(InvalidDataParam, fmt.Sprintf("payment amount %q is invalid", payment.Amount))}
+	}
+	if !amount.Equal(total) {
+		return payment, []Error{orderError(InvalidDataParam, fmt.Sprintf("payment amount %s does not match the offer total plus services of %s", amount, total))}
+	}
+	if payment.Type == "" {
+		payment.Type = PaymentMethodBalance
+	}
+	return payment, nil
+}
+
+func (b *OrderBuilder) servicePrice(s ServiceCreateInput) (currency.Amount, bool) {
+	if price, ok := b.seatPrices[s.ID]; ok {
+		return price, true
+	}
+	available := b.availableService(s.ID)
+	if available == nil {
+		return currency.Amount{}, false
+	}
+	price, err := currency.NewAmount(available.RawTotalAmount, available.RawTotalCurrency)
+	if err != nil {
+		return currency.Amount{}, false
+	}
+	if s.Quantity > 1 {
+		price, err = price.Mul(fmt.Sprint(s.Quantity))
+		if err != nil {
+			return currency.Amount{}, false
+		}
+	}
+	return price, true
+}
+
+func (b *OrderBuilder) availableService(id string) *AvailableService {
+	for i := range b.offer.AvailableServices {
+		if b.offer.AvailableServices[i].ID == id {
+			return &b.offer.AvailableServices[i]
+		}
+	}
+	return nil
+}
+
+func orderError(code ErrorCode, message string) Error {
+	return Error{
+		Type:    ValidationError,
+		Title:   "Invalid order",
+		Message: message,
+		Code:    code,
+	}
+}
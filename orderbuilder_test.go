@@ -0,0 +1,158 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testOrderBuilderOffer() *Offer {
+	return &Offer{
+		ID:               "off_123",
+		RawTotalAmount:   "100.00",
+		RawTotalCurrency: "GBP",
+		Passengers: []OfferRequestPassenger{
+			{ID: "pas_1"},
+			{ID: "pas_2"},
+		},
+		AvailableServices: []AvailableService{
+			{ID: "ase_bag", MaximumQuantity: 2, Type: ServiceTypeBaggage, RawTotalAmount: "25.00", RawTotalCurrency: "GBP"},
+		},
+	}
+}
+
+func testOrderPassenger(id, givenName string) OrderPassenger {
+	return OrderPassenger{
+		ID:          id,
+		Title:       PassengerTitleMs,
+		GivenName:   givenName,
+		FamilyName:  "Earhart",
+		BornOn:      Date(time.Date(1987, 7, 24, 0, 0, 0, 0, time.UTC)),
+		Email:       "amelia@example.com",
+		Gender:      GenderFemale,
+		PhoneNumber: "+442080160509",
+	}
+}
+
+func TestOrderBuilder(t *testing.T) {
+	a := assert.New(t)
+
+	input, err := NewOrderBuilder(testOrderBuilderOffer()).
+		Passengers(testOrderPassenger("pas_1", "Amelia"), testOrderPassenger("pas_2", "Muriel")).
+		Service("ase_bag", 2).
+		Metadata(Metadata{"ref": "abc"}).
+		Build()
+	require.NoError(t, err)
+
+	a.Equal(OrderTypeInstant, input.Type)
+	a.Equal([]string{"off_123"}, input.SelectedOffers)
+	a.Len(input.Passengers, 2)
+	a.Equal([]ServiceCreateInput{{ID: "ase_bag", Quantity: 2}}, input.Services)
+	a.Equal([]PaymentCreateInput{{Amount: "150.00", Currency: "GBP", Type: PaymentMethodBalance}}, input.Payments)
+	a.Equal(Metadata{"ref": "abc"}, input.Metadata)
+
+	input, err = NewOrderBuilder(testOrderBuilderOffer()).
+		Passengers(testOrderPassenger("pas_1", "Amelia"), testOrderPassenger("pas_2", "Muriel")).
+		Payment(PaymentCreateInput{Amount: "100", Currency: "GBP", Type: ARCBSPCash}).
+		Build()
+	require.NoError(t, err)
+	a.Equal(ARCBSPCash, input.Payments[0].Type)
+
+	input, err = NewOrderBuilder(testOrderBuilderOffer()).
+		Passengers(testOrderPassenger("pas_1", "Amelia"), testOrderPassenger("pas_2", "Muriel")).
+		Hold().
+		Build()
+	require.NoError(t, err)
+	a.Equal(OrderTypeHold, input.Type)
+	a.Empty(input.Payments)
+}
+
+func TestOrderBuilderValidation(t *testing.T) {
+	a := assert.New(t)
+
+	offer := testOrderBuilderOffer()
+	offer.PassengerIdentityDocumentsRequired = true
+	offer.AllowedPassengerIdentityDocumentTypes = []string{"passport"}
+
+	passport := IdentityDocument{UniqueIdentifier: "KP1234567", ExpiresOn: Date(time.Now().AddDate(2, 0, 0)), IssuingCountryCode: "GB", Type: "passport"}
+	withPassport := func(p OrderPassenger, doc IdentityDocument) OrderPassenger {
+		p.IdentityDocuments = []IdentityDocument{doc}
+		return p
+	}
+
+	err := new(OrderBuilder).
+		FromOffer(offer).
+		Passengers(
+			withPassport(testOrderPassenger("pas_1", "Amelia"), passport),
+			withPassport(testOrderPassenger("pas_1", "Amelia"), passport),
+			testOrderPassenger("pas_3", "Muriel"),
+		).
+		Service("ase_bag", 3).
+		Service("ase_unknown", 1).
+		Payment(PaymentCreateInput{Amount: "100.00", Currency: "USD"}).
+		Validate()
+	require.Error(t, err)
+
+	derr, ok := err.(*DuffelError)
+	require.True(t, ok)
+	messages := make([]string, len(derr.Errors))
+	for i, e := range derr.Errors {
+		a.Equal(ValidationError, e.Type)
+		messages[i] = e.Message
+	}
+	a.Equal([]string{
+		"passenger pas_1 is listed more than once",
+		"passenger pas_3 is not on offer off_123",
+		"passenger pas_2 is missing",
+		"service ase_bag can be booked at most 2 times, not 3",
+		"service ase_unknown is not available on offer off_123",
+		"payment currency USD does not match offer currency GBP",
+	}, messages)
+	a.True(IsErrorCode(err, AncillaryServiceNotAvailable))
+
+	err = NewOrderBuilder(offer).
+		Passengers(testOrderPassenger("pas_1", "Amelia"), withPassport(testOrderPassenger("pas_2", "Muriel"), IdentityDocument{UniqueIdentifier: "123", ExpiresOn: passport.ExpiresOn, IssuingCountryCode: "GB", Type: "tax_id"})).
+		Payment(PaymentCreateInput{Amount: "99.99", Currency: "GBP"}).
+		Validate()
+	a.EqualError(err, "duffel: passenger pas_1 needs an identity document for offer off_123")
+	a.Len(err.(*DuffelError).Errors, 3)
+	a.Equal("passenger pas_2 has a tax_id but offer off_123 only accepts passport", err.(*DuffelError).Errors[1].Message)
+	a.Equal("payment amount 99.99 GBP does not match the offer total plus services of 100.00 GBP", err.(*DuffelError).Errors[2].Message)
+
+	offer = testOrderBuilderOffer()
+	offer.PaymentRequirements.RequiresInstantPayment = true
+	err = NewOrderBuilder(offer).
+		Passengers(testOrderPassenger("pas_1", "Amelia"), testOrderPassenger("pas_2", "Muriel")).
+		Service("ase_bag", 1).
+		Hold().
+		Validate()
+	require.Error(t, err)
+	a.Len(err.(*DuffelError).Errors, 2)
+
+	a.Error(new(OrderBuilder).Validate())
+}
+
+func TestOrderBuilderSeats(t *testing.T) {
+	a := assert.New(t)
+
+	seatmap := loadTestSeatmap(t)
+	offer := testOrderBuilderOffer()
+	offer.Passengers = offer.Passengers[:1]
+	seatmap.Cabins[0].Rows[0].Sections[0].Elements[0].AvailableServices[0].PassengerID = "pas_1"
+	selection := NewSeatSelection([]*Seatmap{seatmap})
+	require.NoError(t, selection.Select(seatmap.SegmentID, "pas_1", "1A"))
+
+	input, err := NewOrderBuilder(offer).
+		Passengers(testOrderPassenger("pas_1", "Amelia")).
+		Seats(selection).
+		Build()
+	require.NoError(t, err)
+	a.Equal("130.00", input.Payments[0].Amount)
+	a.Len(input.Services, 1)
+}
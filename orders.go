@@ -56,11 +56,21 @@ type (
 		RawPenaltyCurrency *string `json:"penalty_currency,omitempty"`
 	}
 
+	// Document is a ticketing document issued by the airline for an order.
 	Document struct {
-		Type             string `json:"type"`
+		// The type of document, e.g. an electronic ticket or an EMD issued for services.
+		// See DocumentType for the typed value.
+		Type string `json:"type"`
+
+		// The identifier of the document, e.g. the 13 digit e-ticket number.
 		UniqueIdentifier string `json:"unique_identifier"`
+
+		// The ids of the passengers this document was issued for.
+		PassengerIDs []string `json:"passenger_ids,omitempty"`
 	}
 
+	DocumentType string
+
 	// NOTE: If you receive a 500 Internal Server Error when trying to create an order,
 	// it may have still been created on the airline’s side.
 	// Please contact Duffel support before trying the request again.
@@ -133,6 +143,9 @@ type (
 	}
 
 	ListOrdersParams struct {
+		// ListOptions sets the cursor to start listing from, e.g. to resume a sync.
+		ListOptions
+
 		// Filters orders by their booking reference.
 		// The filter requires an exact match but is case insensitive.
 		BookingReference string `url:"booking_reference,omitempty"`
@@ -201,6 +214,12 @@ const (
 
 	OrderTypeHold    OrderType = "hold"
 	OrderTypeInstant OrderType = "instant"
+
+	DocumentTypeElectronicTicket DocumentType = "electronic_ticket"
+	// An EMD issued for services that are associated with a ticket, e.g. seats or bags.
+	DocumentTypeEMDAssociated DocumentType = "electronic_miscellaneous_document_associated"
+	// An EMD issued for services that are not associated with a ticket.
+	DocumentTypeEMDStandalone DocumentType = "electronic_miscellaneous_document_standalone"
 )
 
 // CreateOrder creates a new order.
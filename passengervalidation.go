@@ -0,0 +1,263 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// e164 matches phone numbers in E.164 format, e.g. +442080160509.
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// ValidatePassengers checks the passenger details for an order against the offer,
+// returning a *DuffelError with an Error for every problem found. Each error's Source
+// points to the field at fault, e.g. "/passengers/0/phone_number".
+//
+// It checks that:
+//
+//   - every passenger on the offer has details, and no others
+//   - required fields are present, and phone numbers are in E.164 format
+//   - dates of birth match the age or type given in the offer request
+//   - identity documents are present if required, of an allowed type, and
+//     don't expire before the end of the journey
+//   - each infant travels with a different adult
+//   - no two passengers have the same name
+func ValidatePassengers(offer *Offer, passengers []OrderPassenger) error {
+	errs := validatePassengers(offer, passengers)
+	if len(errs) == 0 {
+		return nil
+	}
+	return &DuffelError{Errors: errs}
+}
+
+func validatePassengers(offer *Offer, passengers []OrderPassenger) []Error {
+	var errs []Error
+
+	offerPassengers := make(map[string]*OfferRequestPassenger, len(offer.Passengers))
+	for i := range offer.Passengers {
+		offerPassengers[offer.Passengers[i].ID] = &offer.Passengers[i]
+	}
+	firstDeparture, lastDeparture, lastArrival := journeyDates(offer.Slices)
+
+	seen := make(map[string]bool, len(passengers))
+	names := make(map[string]string, len(passengers))
+	infants := make(map[string]string)
+
+	for i := range passengers {
+		p := &passengers[i]
+		field := func(name string) *ErrorSource {
+			return &ErrorSource{Field: name, Pointer: fmt.Sprintf("/passengers/%d/%s", i, name)}
+		}
+
+		op, ok := offerPassengers[p.ID]
+		switch {
+		case p.ID == "":
+			errs = append(errs, passengerError(InvalidDataParam, field("id"), fmt.Sprintf("passenger %s %s is missing an id", p.GivenName, p.FamilyName)))
+			continue
+		case !ok:
+			errs = append(errs, passengerError(InvalidDataParam, field("id"), fmt.Sprintf("passenger %s is not on offer %s", p.ID, offer.ID)))
+			continue
+		case seen[p.ID]:
+			errs = append(errs, passengerError(InvalidDataParam, field("id"), fmt.Sprintf("passenger %s is listed more than once", p.ID)))
+			continue
+		}
+		seen[p.ID] = true
+
+		switch p.Title {
+		case PassengerTitleMr, PassengerTitleMs, PassengerTitleMrs, PassengerTitleMiss:
+		case "":
+			errs = append(errs, passengerError(InvalidDataParam, field("title"), fmt.Sprintf("passenger %s is missing a title", p.ID)))
+		default:
+			errs = append(errs, passengerError(InvalidDataParam, field("title"), fmt.Sprintf("passenger %s has an unknown title %q", p.ID, p.Title)))
+		}
+		if strings.TrimSpace(p.GivenName) == "" {
+			errs = append(errs, passengerError(InvalidDataParam, field("given_name"), fmt.Sprintf("passenger %s is missing a given name", p.ID)))
+		}
+		if strings.TrimSpace(p.FamilyName) == "" {
+			errs = append(errs, passengerError(InvalidDataParam, field("family_name"), fmt.Sprintf("passenger %s is missing a family name", p.ID)))
+		}
+		switch p.Gender {
+		case GenderMale, GenderFemale:
+		default:
+			errs = append(errs, passengerError(InvalidDataParam, field("gender"), fmt.Sprintf("passenger %s has an invalid gender %q", p.ID, p.Gender)))
+		}
+		if at := strings.Index(p.Email, "@"); at < 1 || at == len(p.Email)-1 {
+			errs = append(errs, passengerError(InvalidDataParam, field("email"), fmt.Sprintf("passenger %s has an invalid email address %q", p.ID, p.Email)))
+		}
+		if !e164.MatchString(p.PhoneNumber) {
+			errs = append(errs, passengerError(InvalidDataParam, field("phone_number"), fmt.Sprintf("passenger %s has phone number %q, which is not in E.164 format, e.g. +442080160509", p.ID, p.PhoneNumber)))
+		}
+
+		bornOn := time.Time(p.BornOn)
+		switch {
+		case bornOn.IsZero():
+			errs = append(errs, passengerError(InvalidDataParam, field("born_on"), fmt.Sprintf("passenger %s is missing a date of birth", p.ID)))
+		case !firstDeparture.IsZero() && bornOn.After(firstDeparture):
+			errs = append(errs, passengerError(InvalidDataParam, field("born_on"), fmt.Sprintf("passenger %s is born after the journey starts", p.ID)))
+		case !lastDeparture.IsZero():
+			if msg := checkPassengerAge(op, ageOn(bornOn, lastDeparture)); msg != "" {
+				errs = append(errs, passengerError(InvalidDataParam, field("born_on"), fmt.Sprintf("passenger %s %s", p.ID, msg)))
+			}
+		}
+
+		errs = append(errs, validateIdentityDocuments(offer, p, lastArrival, field)...)
+
+		if p.InfantPassengerID != "" {
+			infant, ok := offerPassengers[p.InfantPassengerID]
+			switch {
+			case !ok:
+				errs = append(errs, passengerError(InvalidDataParam, field("infant_passenger_id"), fmt.Sprintf("passenger %s is responsible for infant %s, who is not on offer %s", p.ID, p.InfantPassengerID, offer.ID)))
+			case p.InfantPassengerID == p.ID || !isInfant(infant):
+				errs = append(errs, passengerError(InvalidDataParam, field("infant_passenger_id"), fmt.Sprintf("passenger %s is responsible for passenger %s, who is not an infant", p.ID, p.InfantPassengerID)))
+			case !isAdult(op):
+				errs = append(errs, passengerError(InvalidDataParam, field("infant_passenger_id"), fmt.Sprintf("passenger %s can't be responsible for an infant", p.ID)))
+			case infants[p.InfantPassengerID] != "":
+				errs = append(errs, passengerError(InvalidDataParam, field("infant_passenger_id"), fmt.Sprintf("infant %s is already travelling with passenger %s", p.InfantPassengerID, infants[p.InfantPassengerID])))
+			default:
+				infants[p.InfantPassengerID] = p.ID
+			}
+		}
+
+		name := strings.ToLower(strings.TrimSpace(p.GivenName) + " " + strings.TrimSpace(p.FamilyName))
+		if other, ok := names[name]; ok && strings.TrimSpace(name) != "" {
+			errs = append(errs, passengerError(DuplicatePassengerName, field("given_name"), fmt.Sprintf("passengers %s and %s have the same name", other, p.ID)))
+		}
+		names[name] = p.ID
+	}
+
+	for i := range offer.Passengers {
+		op := &offer.Passengers[i]
+		if !seen[op.ID] {
+			errs = append(errs, passengerError(InvalidDataParam, &ErrorSource{Field: "passengers", Pointer: "/passengers"}, fmt.Sprintf("passenger %s is missing", op.ID)))
+			continue
+		}
+		if isInfant(op) && infants[op.ID] == "" {
+			errs = append(errs, passengerError(InvalidDataParam, &ErrorSource{Field: "infant_passenger_id", Pointer: "/passengers"}, fmt.Sprintf("infant %s must travel with an adult", op.ID)))
+		}
+	}
+	return errs
+}
+
+func validateIdentityDocuments(offer *Offer, p *OrderPassenger, lastArrival time.Time, field func(string) *ErrorSource) []Error {
+	var errs []Error
+	switch {
+	case len(p.IdentityDocuments) > 1:
+		return append(errs, passengerError(InvalidDataParam, field("identity_documents"), fmt.Sprintf("passenger %s has more than one identity document", p.ID)))
+	case len(p.IdentityDocuments) == 0:
+		if offer.PassengerIdentityDocumentsRequired {
+			errs = append(errs, passengerError(InvalidDataParam, field("identity_documents"), fmt.Sprintf("passenger %s needs an identity document for offer %s", p.ID, offer.ID)))
+		}
+		return errs
+	}
+
+	doc := p.IdentityDocuments[0]
+	docField := func(name string) *ErrorSource {
+		source := field("identity_documents/0/" + name)
+		source.Field = name
+		return source
+	}
+	if len(offer.AllowedPassengerIdentityDocumentTypes) > 0 && !containsString(offer.AllowedPassengerIdentityDocumentTypes, doc.Type) {
+		errs = append(errs, passengerError(InvalidDataParam, docField("type"), fmt.Sprintf("passenger %s has a %s but offer %s only accepts %s",
+			p.ID, doc.Type, offer.ID, strings.Join(offer.AllowedPassengerIdentityDocumentTypes, ", "))))
+	}
+	if strings.TrimSpace(doc.UniqueIdentifier) == "" {
+		errs = append(errs, passengerError(InvalidDataParam, docField("unique_identifier"), fmt.Sprintf("passenger %s's identity document is missing its number", p.ID)))
+	}
+	if len(doc.IssuingCountryCode) != 2 {
+		errs = append(errs, passengerError(InvalidDataParam, docField("issuing_country_code"), fmt.Sprintf("passenger %s's identity document has issuing country %q, which is not an ISO 3166-1 alpha-2 code", p.ID, doc.IssuingCountryCode)))
+	}
+
+	expiresOn := time.Time(doc.ExpiresOn)
+	switch {
+	case expiresOn.IsZero():
+		errs = append(errs, passengerError(InvalidDataParam, docField("expires_on"), fmt.Sprintf("passenger %s's identity document is missing its expiry date", p.ID)))
+	case !lastArrival.IsZero() && expiresOn.Before(lastArrival):
+		errs = append(errs, passengerError(InvalidDataParam, docField("expires_on"), fmt.Sprintf("passenger %s's identity document expires on %s, before the journey ends on %s",
+			p.ID, doc.ExpiresOn, Date(lastArrival))))
+	}
+	return errs
+}
+
+// checkPassengerAge compares a passenger's age at the last departure of the journey,
+// which is when Duffel measures it, with the age or type given in the offer request.
+func checkPassengerAge(op *OfferRequestPassenger, age int) string {
+	switch {
+	case op.Age > 0 && age != op.Age:
+		return fmt.Sprintf("is %d on the last departure of the journey but was searched for as %d", age, op.Age)
+	case op.Age > 0:
+		return ""
+	case op.Type == PassengerTypeAdult && age < 18:
+		return fmt.Sprintf("is %d on the last departure of the journey, too young to be an adult", age)
+	case op.Type == PassengerTypeChild && (age < 2 || age > 17):
+		return fmt.Sprintf("is %d on the last departure of the journey, which is not a child's age", age)
+	case op.Type == PassengerTypeInfantWithoutSeat && age > 1:
+		return fmt.Sprintf("is %d on the last departure of the journey, too old to be an infant", age)
+	}
+	return ""
+}
+
+// isAdult reports whether the passenger was searched for as an adult, which passengers
+// without an age or type are assumed to be.
+func isAdult(op *OfferRequestPassenger) bool {
+	if op.Age > 0 {
+		return op.Age >= 18
+	}
+	return op.Type == "" || op.Type == PassengerTypeAdult
+}
+
+// isInfant reports whether the passenger was searched for as an infant, either
+// by type or with an age under 2.
+func isInfant(op *OfferRequestPassenger) bool {
+	if op.Age > 0 {
+		return op.Age < 2
+	}
+	return op.Type == PassengerTypeInfantWithoutSeat
+}
+
+// ageOn returns the age in whole years of someone born on bornOn, on the given date.
+func ageOn(bornOn, on time.Time) int {
+	age := on.Year() - bornOn.Year()
+	if on.Month() < bornOn.Month() || (on.Month() == bornOn.Month() && on.Day() < bornOn.Day()) {
+		age--
+	}
+	return age
+}
+
+// journeyDates returns the local dates of the first departure, the last departure and
+// the last arrival of the slices, or zero times if they can't be parsed.
+func journeyDates(slices []Slice) (firstDeparture, lastDeparture, lastArrival time.Time) {
+	date := func(raw string) time.Time {
+		t, err := time.Parse(localTimeFormat, raw)
+		if err != nil {
+			return time.Time{}
+		}
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	for i, slice := range slices {
+		if len(slice.Segments) == 0 {
+			continue
+		}
+		if i == 0 {
+			firstDeparture = date(slice.Segments[0].RawDepartingAt)
+		}
+		lastDeparture = date(slice.Segments[0].RawDepartingAt)
+		lastArrival = date(slice.Segments[len(slice.Segments)-1].RawArrivingAt)
+	}
+	return firstDeparture, lastDeparture, lastArrival
+}
+
+func passengerError(code ErrorCode, source *ErrorSource, message string) Error {
+	return Error{
+		Type:    ValidationError,
+		Title:   "Invalid passenger",
+		Message: message,
+		Code:    code,
+		Source:  source,
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPassengerOffer() *Offer {
+	return &Offer{
+		ID: "off_123",
+		Passengers: []OfferRequestPassenger{
+			{ID: "pas_adult", Type: PassengerTypeAdult},
+			{ID: "pas_child", Age: 8},
+			{ID: "pas_infant", Type: PassengerTypeInfantWithoutSeat},
+		},
+		PassengerIdentityDocumentsRequired:    true,
+		AllowedPassengerIdentityDocumentTypes: []string{"passport"},
+		Slices: []Slice{
+			{Segments: []Flight{{RawDepartingAt: "2026-11-20T09:00:00", RawArrivingAt: "2026-11-20T12:00:00"}}},
+			{Segments: []Flight{{RawDepartingAt: "2026-11-27T22:00:00", RawArrivingAt: "2026-11-28T06:00:00"}}},
+		},
+	}
+}
+
+func testPassengers() []OrderPassenger {
+	passport := func(number string) []IdentityDocument {
+		return []IdentityDocument{{
+			UniqueIdentifier:   number,
+			ExpiresOn:          Date(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)),
+			IssuingCountryCode: "GB",
+			Type:               "passport",
+		}}
+	}
+	adult := testOrderPassenger("pas_adult", "Amelia")
+	adult.IdentityDocuments = passport("KP1")
+	adult.InfantPassengerID = "pas_infant"
+
+	child := testOrderPassenger("pas_child", "Muriel")
+	child.Title = PassengerTitleMiss
+	child.BornOn = Date(time.Date(2018, 11, 27, 0, 0, 0, 0, time.UTC))
+	child.IdentityDocuments = passport("KP2")
+
+	infant := testOrderPassenger("pas_infant", "Grace")
+	infant.BornOn = Date(time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC))
+	infant.IdentityDocuments = passport("KP3")
+
+	return []OrderPassenger{adult, child, infant}
+}
+
+func TestValidatePassengers(t *testing.T) {
+	assert.NoError(t, ValidatePassengers(testPassengerOffer(), testPassengers()))
+}
+
+func TestValidatePassengersFieldErrors(t *testing.T) {
+	a := assert.New(t)
+
+	passengers := testPassengers()
+	passengers[0].PhoneNumber = "020 8016 0509"
+	passengers[0].IdentityDocuments[0].ExpiresOn = Date(time.Date(2026, 11, 25, 0, 0, 0, 0, time.UTC))
+	// A day too young for the age in the offer request.
+	passengers[1].BornOn = Date(time.Date(2018, 11, 28, 0, 0, 0, 0, time.UTC))
+	passengers[2].IdentityDocuments[0].Type = "tax_id"
+	passengers[2].Title = ""
+
+	err := ValidatePassengers(testPassengerOffer(), passengers)
+	require.Error(t, err)
+	a.True(IsErrorType(err, ValidationError))
+
+	derr := err.(*DuffelError)
+	pointers := make([]string, len(derr.Errors))
+	for i, e := range derr.Errors {
+		require.NotNil(t, e.Source)
+		pointers[i] = e.Source.Pointer
+	}
+	a.Equal([]string{
+		"/passengers/0/phone_number",
+		"/passengers/0/identity_documents/0/expires_on",
+		"/passengers/1/born_on",
+		"/passengers/2/title",
+		"/passengers/2/identity_documents/0/type",
+	}, pointers)
+
+	a.Equal("phone_number", derr.Errors[0].Source.Field)
+	a.Equal("expires_on", derr.Errors[1].Source.Field)
+	a.Equal("passenger pas_adult's identity document expires on 2026-11-25, before the journey ends on 2026-11-28", derr.Errors[1].Message)
+	a.Equal("passenger pas_child is 7 on the last departure of the journey but was searched for as 8", derr.Errors[2].Message)
+}
+
+func TestValidatePassengersInfants(t *testing.T) {
+	a := assert.New(t)
+
+	passengers := testPassengers()
+	passengers[0].InfantPassengerID = ""
+	err := ValidatePassengers(testPassengerOffer(), passengers)
+	a.EqualError(err, "duffel: infant pas_infant must travel with an adult")
+
+	passengers[0].InfantPassengerID = "pas_child"
+	err = ValidatePassengers(testPassengerOffer(), passengers)
+	require.Error(t, err)
+	a.Equal("passenger pas_adult is responsible for passenger pas_child, who is not an infant", err.(*DuffelError).Errors[0].Message)
+
+	passengers = testPassengers()
+	passengers[0].InfantPassengerID = ""
+	passengers[1].InfantPassengerID = "pas_infant"
+	err = ValidatePassengers(testPassengerOffer(), passengers)
+	a.EqualError(err, "duffel: passenger pas_child can't be responsible for an infant")
+
+	// Passengers searched for by age are infants if they are under 2.
+	offer := testPassengerOffer()
+	offer.Passengers[2].Type = ""
+	offer.Passengers[2].Age = 1
+	passengers = testPassengers()
+	passengers[2].BornOn = Date(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC))
+	a.NoError(ValidatePassengers(offer, passengers))
+
+	offer.Passengers[2].Age = 2
+	passengers = testPassengers()
+	passengers[2].BornOn = Date(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	err = ValidatePassengers(offer, passengers)
+	a.EqualError(err, "duffel: passenger pas_adult is responsible for passenger pas_infant, who is not an infant")
+}
+
+func TestValidatePassengersDuplicateName(t *testing.T) {
+	passengers := testPassengers()
+	passengers[1].GivenName = "amelia "
+
+	err := ValidatePassengers(testPassengerOffer(), passengers)
+	assert.True(t, IsErrorCode(err, DuplicatePassengerName))
+	assert.EqualError(t, err, "duffel: passengers pas_adult and pas_child have the same name")
+}
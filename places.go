@@ -4,12 +4,16 @@
 
 package duffel
 
-import "context"
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
 
 type (
 	PlacesClient interface {
-		PlaceSuggestions(ctx context.Context, query string) ([]*Place, error)
-		Cities(ctx context.Context) *Iter[City]
+		PlaceSuggestions(ctx context.Context, query string, params ...PlaceSuggestionsParams) ([]*Place, error)
+		Cities(ctx context.Context, params ...ListCitiesParams) *Iter[City]
 		City(ctx context.Context, id string) (*City, error)
 	}
 
@@ -31,19 +35,48 @@ type (
 	}
 
 	PlaceType string
+
+	ListCitiesParams struct {
+		ListOptions
+	}
+
+	// PlaceSuggestionsParams narrows place suggestions to those around a point.
+	// The query may be left empty to search by location alone.
+	PlaceSuggestionsParams struct {
+		Latitude  float64
+		Longitude float64
+		// RadiusMeters is the distance from the point to search within.
+		RadiusMeters int
+	}
 )
 
 const PlaceTypeAirport = "airport"
 const PlaceTypeCity = "city"
 
-func (a *API) PlaceSuggestions(ctx context.Context, query string) ([]*Place, error) {
-	return newRequestWithAPI[EmptyPayload, Place](a).
-		Get("/places/suggestions").WithParam("query", query).
-		Slice(ctx)
+func (a *API) PlaceSuggestions(ctx context.Context, query string, params ...PlaceSuggestionsParams) ([]*Place, error) {
+	req := newRequestWithAPI[PlaceSuggestionsParams, Place](a).
+		Get("/places/suggestions").
+		WithParams(normalizeParams(params)...)
+	if query != "" || len(params) == 0 {
+		req = req.WithParam("query", query)
+	}
+	return req.Slice(ctx)
+}
+
+func (a *API) Cities(ctx context.Context, params ...ListCitiesParams) *Iter[City] {
+	return newRequestWithAPI[ListCitiesParams, City](a).
+		Get("/air/cities").
+		WithParams(normalizeParams(params)...).
+		Iter(ctx)
 }
 
-func (a *API) Cities(ctx context.Context) *Iter[City] {
-	return newRequestWithAPI[EmptyPayload, City](a).Get("/air/cities").Iter(ctx)
+func (p PlaceSuggestionsParams) Encode(q url.Values) error {
+	q.Set("lat", strconv.FormatFloat(p.Latitude, 'f', -1, 64))
+	q.Set("lng", strconv.FormatFloat(p.Longitude, 'f', -1, 64))
+	if p.RadiusMeters > 0 {
+		q.Set("rad", strconv.Itoa(p.RadiusMeters))
+	}
+	return nil
 }
 
 func (a *API) City(ctx context.Context, id string) (*City, error) {
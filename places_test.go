@@ -40,3 +40,32 @@ func TestPlacesSuggestions(t *testing.T) {
 	a.Equal("London", places[0].CityName)
 	a.Equal("Heathrow", places[0].Airports[0].Name)
 }
+
+func TestPlacesSuggestionsNearby(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+
+	gock.New("https://api.duffel.com").
+		Get("/places/suggestions").
+		MatchParam("lat", "51.4703").
+		MatchParam("lng", "-0.4581").
+		MatchParam("rad", "50000").
+		Reply(200).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/200-get-places-suggestion.json")
+
+	ctx := context.TODO()
+
+	client := New("duffel_test_123")
+	places, err := client.PlaceSuggestions(ctx, "", PlaceSuggestionsParams{
+		Latitude:     51.4703,
+		Longitude:    -0.4581,
+		RadiusMeters: 50000,
+	})
+	a.NoError(err)
+	a.NotEmpty(places)
+	a.True(gock.IsDone())
+}
@@ -0,0 +1,342 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bojanz/currency"
+	"golang.org/x/time/rate"
+)
+
+type (
+	// rateLimitedClient is a client whose requests wait on a rate limiter shared between them, such as *API.
+	rateLimitedClient interface {
+		RateLimiter() *rate.Limiter
+	}
+
+	// DateWindow is how many days either side of a date to search.
+	DateWindow struct {
+		DaysBefore int
+		DaysAfter  int
+	}
+
+	// PriceCalendarOption configures SearchPriceCalendar.
+	PriceCalendarOption func(*priceCalendarOptions)
+
+	priceCalendarOptions struct {
+		concurrency int
+		limiter     *rate.Limiter
+	}
+
+	// PriceCalendar is the cheapest price for each pair of outbound and return dates.
+	PriceCalendar struct {
+		OutboundDates []Date
+		// ReturnDates is empty for one-way searches.
+		ReturnDates []Date
+		// Cells are indexed by outbound date, then return date. One-way searches have a
+		// single column. Cells where the return would be before the outbound are nil.
+		Cells [][]*PriceCalendarCell
+	}
+
+	// PriceCalendarCell is the result of searching a single pair of dates.
+	PriceCalendarCell struct {
+		OutboundDate Date
+		// ReturnDate is zero for one-way searches.
+		ReturnDate Date
+
+		OfferRequestID string
+		// Cheapest is the lowest total amount of any offer, if Found is true.
+		Cheapest currency.Amount
+		Found    bool
+		// OfferIDs are the offers priced at Cheapest.
+		OfferIDs []string
+		// MixedCurrencies is true if some offers were priced in a different currency
+		// from the first offer found, and so were left out.
+		MixedCurrencies bool
+
+		// Err is why searching these dates failed, in which case the other fields are empty.
+		// Dates that weren't searched because the context was cancelled have its error.
+		Err error
+	}
+)
+
+// FlexibleDates returns a window of the given number of days either side of a date, e.g. ±3 days.
+func FlexibleDates(days int) DateWindow {
+	return DateWindow{DaysBefore: days, DaysAfter: days}
+}
+
+// WithCalendarConcurrency sets how many offer requests run at once. It defaults to 4.
+func WithCalendarConcurrency(n int) PriceCalendarOption {
+	return func(o *priceCalendarOptions) {
+		o.concurrency = n
+	}
+}
+
+// WithCalendarRateLimiter sets a rate limiter to wait on before each offer request.
+// By default, requests made with an *API stay within the rate limit it shares between
+// all of its requests, and requests made with other clients are limited to 5 per second.
+func WithCalendarRateLimiter(limiter *rate.Limiter) PriceCalendarOption {
+	return func(o *priceCalendarOptions) {
+		o.limiter = limiter
+	}
+}
+
+// SearchPriceCalendar searches for offers on every date in the window around the
+// departure dates of the input's slices, and returns the cheapest price for each pair
+// of outbound and return dates. The first slice is the outbound and the second, if
+// any, the return. Any further slices keep their dates.
+//
+// Offer requests run concurrently, within the concurrency cap and the rate limit shared
+// by the client's requests. A failed
+// search is recorded in its cell rather than failing the calendar, unless every search
+// fails, in which case the first error is returned. If ctx is cancelled, the searches
+// completed so far are returned along with the context's error.
+func SearchPriceCalendar(ctx context.Context, client OfferRequestCreator, input OfferRequestInput, window DateWindow, opts ...PriceCalendarOption) (*PriceCalendar, error) {
+	o := &priceCalendarOptions{
+		concurrency: 4,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.concurrency < 1 {
+		o.concurrency = 1
+	}
+	o.limiter = defaultLimiter(client, o.limiter)
+	if len(input.Slices) == 0 {
+		return nil, &DuffelError{Errors: []Error{priceCalendarError("at least one slice is required")}}
+	}
+	if window.DaysBefore < 0 || window.DaysAfter < 0 {
+		return nil, &DuffelError{Errors: []Error{priceCalendarError(
+			fmt.Sprintf("the date window can't be negative, got %d days before and %d after", window.DaysBefore, window.DaysAfter))}}
+	}
+
+	calendar := &PriceCalendar{
+		OutboundDates: windowDates(input.Slices[0].DepartureDate, window),
+	}
+	if len(input.Slices) > 1 {
+		calendar.ReturnDates = windowDates(input.Slices[1].DepartureDate, window)
+	}
+
+	var cells []*PriceCalendarCell
+	calendar.Cells = make([][]*PriceCalendarCell, len(calendar.OutboundDates))
+	for i, outbound := range calendar.OutboundDates {
+		if len(calendar.ReturnDates) == 0 {
+			cell := &PriceCalendarCell{OutboundDate: outbound}
+			calendar.Cells[i] = []*PriceCalendarCell{cell}
+			cells = append(cells, cell)
+			continue
+		}
+		calendar.Cells[i] = make([]*PriceCalendarCell, len(calendar.ReturnDates))
+		for j, ret := range calendar.ReturnDates {
+			if time.Time(ret).Before(time.Time(outbound)) {
+				continue
+			}
+			cell := &PriceCalendarCell{OutboundDate: outbound, ReturnDate: ret}
+			calendar.Cells[i][j] = cell
+			cells = append(cells, cell)
+		}
+	}
+
+	jobs := make(chan *PriceCalendarCell)
+	var wg sync.WaitGroup
+	for i := 0; i < o.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for cell := range jobs {
+				searchPriceCalendarCell(ctx, client, o.limiter, input, cell)
+			}
+		}()
+	}
+
+feed:
+	for _, cell := range cells {
+		select {
+		case jobs <- cell:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		for _, cell := range cells {
+			if cell.Err == nil && cell.OfferRequestID == "" {
+				cell.Err = err
+			}
+		}
+		return calendar, err
+	}
+
+	var firstErr error
+	for _, cell := range cells {
+		if cell.Err == nil {
+			return calendar, nil
+		}
+		if firstErr == nil {
+			firstErr = cell.Err
+		}
+	}
+	return calendar, firstErr
+}
+
+func searchPriceCalendarCell(ctx context.Context, client OfferRequestCreator, limiter *rate.Limiter, input OfferRequestInput, cell *PriceCalendarCell) {
+	if limiter != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			cell.Err = err
+			return
+		}
+	}
+
+	req := input
+	req.ReturnOffers = true
+	req.Slices = make([]OfferRequestSlice, len(input.Slices))
+	copy(req.Slices, input.Slices)
+	req.Slices[0].DepartureDate = cell.OutboundDate
+	if len(req.Slices) > 1 {
+		req.Slices[1].DepartureDate = cell.ReturnDate
+	}
+
+	offerRequest, err := client.CreateOfferRequest(ctx, req)
+	if err != nil {
+		cell.Err = err
+		return
+	}
+	cell.OfferRequestID = offerRequest.ID
+
+	for i := range offerRequest.Offers {
+		offer := &offerRequest.Offers[i]
+		total := offer.TotalAmount()
+		if total.CurrencyCode() == "" {
+			continue
+		}
+		if !cell.Found {
+			cell.Cheapest = total
+			cell.Found = true
+			cell.OfferIDs = []string{offer.ID}
+			continue
+		}
+		cmp, err := total.Cmp(cell.Cheapest)
+		switch {
+		case err != nil:
+			cell.MixedCurrencies = true
+		case cmp < 0:
+			cell.Cheapest = total
+			cell.OfferIDs = []string{offer.ID}
+		case cmp == 0:
+			cell.OfferIDs = append(cell.OfferIDs, offer.ID)
+		}
+	}
+}
+
+// Cell returns the cell for the given dates. Pass a zero return date for one-way searches.
+func (c *PriceCalendar) Cell(outbound, ret Date) (*PriceCalendarCell, bool) {
+	for i, d := range c.OutboundDates {
+		if !sameDate(d, outbound) {
+			continue
+		}
+		if len(c.ReturnDates) == 0 {
+			return c.Cells[i][0], true
+		}
+		for j, r := range c.ReturnDates {
+			if sameDate(r, ret) && c.Cells[i][j] != nil {
+				return c.Cells[i][j], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Cheapest returns the cell with the lowest price, or nil if no offers were found.
+// Cells priced in a different currency from the first cell found are left out.
+func (c *PriceCalendar) Cheapest() *PriceCalendarCell {
+	var cheapest *PriceCalendarCell
+	c.each(func(cell *PriceCalendarCell) {
+		if !cell.Found {
+			return
+		}
+		if cheapest == nil {
+			cheapest = cell
+			return
+		}
+		if cmp, err := cell.Cheapest.Cmp(cheapest.Cheapest); err == nil && cmp < 0 {
+			cheapest = cell
+		}
+	})
+	return cheapest
+}
+
+// Errors returns the cells whose searches failed.
+func (c *PriceCalendar) Errors() []*PriceCalendarCell {
+	failed := make([]*PriceCalendarCell, 0)
+	c.each(func(cell *PriceCalendarCell) {
+		if cell.Err != nil {
+			failed = append(failed, cell)
+		}
+	})
+	return failed
+}
+
+// Complete reports whether every pair of dates was searched successfully.
+func (c *PriceCalendar) Complete() bool {
+	complete := true
+	c.each(func(cell *PriceCalendarCell) {
+		if cell.Err != nil {
+			complete = false
+		}
+	})
+	return complete
+}
+
+func (c *PriceCalendar) each(fn func(*PriceCalendarCell)) {
+	for _, row := range c.Cells {
+		for _, cell := range row {
+			if cell != nil {
+				fn(cell)
+			}
+		}
+	}
+}
+
+// defaultLimiter returns the limiter to wait on before each request to the client. It is
+// nil for clients that share a rate limiter between their requests, as each request
+// already waits on it.
+func defaultLimiter(client OfferRequestCreator, limiter *rate.Limiter) *rate.Limiter {
+	if limiter != nil {
+		return limiter
+	}
+	if _, ok := client.(rateLimitedClient); ok {
+		return nil
+	}
+	return rate.NewLimiter(rate.Every(time.Second/5), 5)
+}
+
+// windowDates returns every date in the window around d, in order.
+func windowDates(d Date, window DateWindow) []Date {
+	start := time.Time(d).AddDate(0, 0, -window.DaysBefore)
+	dates := make([]Date, 0, window.DaysBefore+window.DaysAfter+1)
+	for i := 0; i <= window.DaysBefore+window.DaysAfter; i++ {
+		dates = append(dates, Date(start.AddDate(0, 0, i)))
+	}
+	return dates
+}
+
+func sameDate(a, b Date) bool {
+	return time.Time(a).Format(DateFormat) == time.Time(b).Format(DateFormat)
+}
+
+func priceCalendarError(message string) Error {
+	return Error{
+		Type:    ValidationError,
+		Title:   "Invalid price calendar search",
+		Message: message,
+		Code:    InvalidDataParam,
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+	"gopkg.in/h2non/gock.v1"
+)
+
+// fakeCalendarClient prices offers by how many days the outbound is after the 10th,
+// and fails searches for the dates in fail.
+type fakeCalendarClient struct {
+	fail     map[string]bool
+	delay    time.Duration
+	calls    int32
+	active   int32
+	maxAlive int32
+	mu       sync.Mutex
+	inputs   []OfferRequestInput
+}
+
+func (c *fakeCalendarClient) CreateOfferRequest(ctx context.Context, input OfferRequestInput) (*OfferRequest, error) {
+	atomic.AddInt32(&c.calls, 1)
+	active := atomic.AddInt32(&c.active, 1)
+	defer atomic.AddInt32(&c.active, -1)
+	for {
+		max := atomic.LoadInt32(&c.maxAlive)
+		if active <= max || atomic.CompareAndSwapInt32(&c.maxAlive, max, active) {
+			break
+		}
+	}
+
+	c.mu.Lock()
+	c.inputs = append(c.inputs, input)
+	c.mu.Unlock()
+
+	select {
+	case <-time.After(c.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	key := input.Slices[0].DepartureDate.String()
+	if len(input.Slices) > 1 {
+		key += "/" + input.Slices[1].DepartureDate.String()
+	}
+	if c.fail[key] {
+		return nil, fmt.Errorf("search for %s failed", key)
+	}
+
+	day := time.Time(input.Slices[0].DepartureDate).Day()
+	price := fmt.Sprintf("%d.00", 100+(day-10)*10)
+	return &OfferRequest{
+		ID: "orq_" + key,
+		Offers: []Offer{
+			{ID: "off_a_" + key, RawTotalAmount: price, RawTotalCurrency: "GBP"},
+			{ID: "off_b_" + key, RawTotalAmount: "999.00", RawTotalCurrency: "GBP"},
+			{ID: "off_c_" + key, RawTotalAmount: price, RawTotalCurrency: "GBP"},
+		},
+	}, nil
+}
+
+func testCalendarDate(day int) Date {
+	return Date(time.Date(2026, 11, day, 0, 0, 0, 0, time.UTC))
+}
+
+func testCalendarInput() OfferRequestInput {
+	return OfferRequestInput{
+		Passengers: []OfferRequestPassenger{{Type: PassengerTypeAdult}},
+		CabinClass: CabinClassEconomy,
+		Slices: []OfferRequestSlice{
+			{Origin: "LHR", Destination: "JFK", DepartureDate: testCalendarDate(10)},
+			{Origin: "JFK", Destination: "LHR", DepartureDate: testCalendarDate(12)},
+		},
+	}
+}
+
+func unlimited() PriceCalendarOption {
+	return WithCalendarRateLimiter(rate.NewLimiter(rate.Inf, 1))
+}
+
+func TestSearchPriceCalendar(t *testing.T) {
+	a := assert.New(t)
+
+	client := &fakeCalendarClient{
+		fail:  map[string]bool{"2026-11-09/2026-11-13": true},
+		delay: 5 * time.Millisecond,
+	}
+	calendar, err := SearchPriceCalendar(context.Background(), client, testCalendarInput(), FlexibleDates(1), WithCalendarConcurrency(2), unlimited())
+	require.NoError(t, err)
+
+	a.Equal([]Date{testCalendarDate(9), testCalendarDate(10), testCalendarDate(11)}, calendar.OutboundDates)
+	a.Equal([]Date{testCalendarDate(11), testCalendarDate(12), testCalendarDate(13)}, calendar.ReturnDates)
+	a.EqualValues(9, client.calls)
+	a.LessOrEqual(client.maxAlive, int32(2))
+	for _, input := range client.inputs {
+		a.True(input.ReturnOffers)
+	}
+
+	cell, ok := calendar.Cell(testCalendarDate(10), testCalendarDate(12))
+	require.True(t, ok)
+	a.True(cell.Found)
+	a.Equal("100.00 GBP", cell.Cheapest.String())
+	a.Equal([]string{"off_a_2026-11-10/2026-11-12", "off_c_2026-11-10/2026-11-12"}, cell.OfferIDs)
+	a.Equal("orq_2026-11-10/2026-11-12", cell.OfferRequestID)
+
+	cheapest := calendar.Cheapest()
+	require.NotNil(t, cheapest)
+	a.Equal("90.00 GBP", cheapest.Cheapest.String())
+	a.Equal(testCalendarDate(9), cheapest.OutboundDate)
+
+	a.False(calendar.Complete())
+	failed := calendar.Errors()
+	require.Len(t, failed, 1)
+	a.EqualError(failed[0].Err, "search for 2026-11-09/2026-11-13 failed")
+	a.False(failed[0].Found)
+
+	// The base input isn't changed.
+	a.Equal(testCalendarDate(10), testCalendarInput().Slices[0].DepartureDate)
+}
+
+func TestSearchPriceCalendarSkipsReturnsBeforeOutbound(t *testing.T) {
+	a := assert.New(t)
+
+	input := testCalendarInput()
+	input.Slices[1].DepartureDate = testCalendarDate(10)
+
+	client := &fakeCalendarClient{}
+	calendar, err := SearchPriceCalendar(context.Background(), client, input, DateWindow{DaysBefore: 1, DaysAfter: 1}, unlimited())
+	require.NoError(t, err)
+	a.EqualValues(6, client.calls)
+	a.Nil(calendar.Cells[2][0])
+	_, ok := calendar.Cell(testCalendarDate(11), testCalendarDate(9))
+	a.False(ok)
+	a.True(calendar.Complete())
+}
+
+func TestSearchPriceCalendarOneWay(t *testing.T) {
+	a := assert.New(t)
+
+	input := testCalendarInput()
+	input.Slices = input.Slices[:1]
+
+	calendar, err := SearchPriceCalendar(context.Background(), &fakeCalendarClient{}, input, DateWindow{DaysAfter: 2}, unlimited())
+	require.NoError(t, err)
+	a.Empty(calendar.ReturnDates)
+	a.Len(calendar.Cells, 3)
+
+	cell, ok := calendar.Cell(testCalendarDate(12), Date{})
+	require.True(t, ok)
+	a.Equal("120.00 GBP", cell.Cheapest.String())
+}
+
+func TestSearchPriceCalendarErrors(t *testing.T) {
+	a := assert.New(t)
+
+	input := testCalendarInput()
+	input.Slices = input.Slices[:1]
+	client := &fakeCalendarClient{fail: map[string]bool{"2026-11-10": true}}
+	_, err := SearchPriceCalendar(context.Background(), client, input, DateWindow{}, unlimited())
+	a.EqualError(err, "search for 2026-11-10 failed")
+
+	_, err = SearchPriceCalendar(context.Background(), client, OfferRequestInput{}, DateWindow{})
+	a.True(IsErrorCode(err, InvalidDataParam))
+
+	_, err = SearchPriceCalendar(context.Background(), client, input, DateWindow{DaysBefore: -5})
+	a.True(IsErrorCode(err, InvalidDataParam))
+	a.EqualError(err, "duffel: the date window can't be negative, got -5 days before and 0 after")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	client = &fakeCalendarClient{delay: 20 * time.Millisecond}
+	calendar, err := SearchPriceCalendar(ctx, client, testCalendarInput(), FlexibleDates(3), WithCalendarConcurrency(1), unlimited())
+	a.True(errors.Is(err, context.DeadlineExceeded))
+	require.NotNil(t, calendar)
+	a.Less(int(client.calls), 49)
+
+	searched := 0
+	calendar.each(func(cell *PriceCalendarCell) {
+		if cell.Found {
+			searched++
+		} else {
+			a.Error(cell.Err)
+		}
+	})
+	a.Greater(searched, 0)
+}
+
+func TestSearchPriceCalendarAPI(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+
+	gock.New("https://api.duffel.com").
+		Post("/air/offer_requests").
+		MatchParam("return_offers", "true").
+		Times(9).
+		Reply(200).
+		SetHeader(RequestIDHeader, "FvxRwfFNBG8TSt0AAAAB").
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/200-get-offer-request.json")
+
+	// Concurrent requests made with the API share its rate limiter and request ID,
+	// which go test -race checks.
+	client := New("duffel_test_123")
+	calendar, err := SearchPriceCalendar(context.Background(), client, testCalendarInput(), FlexibleDates(1))
+	require.NoError(t, err)
+	a.True(calendar.Complete())
+	a.True(gock.IsDone())
+
+	cheapest := calendar.Cheapest()
+	require.NotNil(t, cheapest)
+	a.Equal("orq_0000AEtEexyvXbB0OhB5jk", cheapest.OfferRequestID)
+	id, _ := client.LastRequestID()
+	a.Equal("FvxRwfFNBG8TSt0AAAAB", id)
+}
+
+type offerRequestCreatorFunc func(ctx context.Context, input OfferRequestInput) (*OfferRequest, error)
+
+func (f offerRequestCreatorFunc) CreateOfferRequest(ctx context.Context, input OfferRequestInput) (*OfferRequest, error) {
+	return f(ctx, input)
+}
+
+func TestSearchPriceCalendarMixedCurrencies(t *testing.T) {
+	a := assert.New(t)
+
+	// Offers in another currency aren't compared by their currency code.
+	client := offerRequestCreatorFunc(func(ctx context.Context, input OfferRequestInput) (*OfferRequest, error) {
+		if time.Time(input.Slices[0].DepartureDate).Day() == 11 {
+			return &OfferRequest{Offers: []Offer{{ID: "off_eur", RawTotalAmount: "10.00", RawTotalCurrency: "EUR"}}}, nil
+		}
+		return &OfferRequest{Offers: []Offer{
+			{ID: "off_gbp", RawTotalAmount: "100.00", RawTotalCurrency: "GBP"},
+			{ID: "off_eur", RawTotalAmount: "50.00", RawTotalCurrency: "EUR"},
+			{ID: "off_gbp_cheaper", RawTotalAmount: "90.00", RawTotalCurrency: "GBP"},
+		}}, nil
+	})
+
+	input := testCalendarInput()
+	input.Slices = input.Slices[:1]
+	calendar, err := SearchPriceCalendar(context.Background(), client, input, DateWindow{DaysAfter: 1}, unlimited())
+	require.NoError(t, err)
+
+	cell, ok := calendar.Cell(testCalendarDate(10), Date{})
+	require.True(t, ok)
+	a.Equal("90.00 GBP", cell.Cheapest.String())
+	a.Equal([]string{"off_gbp_cheaper"}, cell.OfferIDs)
+	a.True(cell.MixedCurrencies)
+
+	cheapest := calendar.Cheapest()
+	require.NotNil(t, cheapest)
+	a.Equal("90.00 GBP", cheapest.Cheapest.String())
+}
@@ -0,0 +1,630 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bojanz/currency"
+)
+
+type (
+	// Criterion is an aspect of an offer that offers can be scored and compared on.
+	Criterion string
+
+	// ScoreWeights sets how much each criterion counts towards an offer's score.
+	// A weight of 0 leaves the criterion out.
+	ScoreWeights map[Criterion]float64
+
+	// DepartureWindow is a preferred range of local departure times,
+	// given as the time since midnight, e.g. 8*time.Hour for 08:00.
+	DepartureWindow struct {
+		From time.Duration
+		To   time.Duration
+	}
+
+	// OfferScorer ranks offers on a weighted combination of criteria.
+	//
+	// Each criterion is scored from 0 (worst) to 1 (best) relative to the other
+	// offers being ranked, so a score only has meaning within the set it was
+	// computed for.
+	OfferScorer struct {
+		weights          ScoreWeights
+		departureWindows []DepartureWindow
+	}
+
+	// OfferScorerOption configures an OfferScorer.
+	OfferScorerOption func(*OfferScorer)
+
+	// ScoredOffer is an offer with its overall score and how it was arrived at.
+	ScoredOffer struct {
+		Offer *Offer
+		// Score is the weighted average of the component scores, from 0 to 1.
+		Score      float64
+		Components []ScoreComponent
+	}
+
+	// ScoreComponent is the contribution of a single criterion to an offer's score.
+	ScoreComponent struct {
+		Criterion Criterion
+		// Value is the raw value of the criterion, e.g. the price or the duration in minutes.
+		// It is NaN if the value is unknown.
+		Value  float64
+		Score  float64
+		Weight float64
+		// Reason is a short human readable explanation of the score.
+		Reason string
+	}
+
+	// OfferHighlights are the offers worth calling out from a ranked set.
+	OfferHighlights struct {
+		Cheapest *Offer
+		Fastest  *Offer
+		Best     *Offer
+	}
+
+	// OfferComparator compares two offers, returning a negative number if a
+	// should come before b, a positive number if after and 0 if they are equal.
+	OfferComparator func(a, b *Offer) int
+)
+
+const (
+	CriterionPrice         Criterion = "price"
+	CriterionDuration      Criterion = "duration"
+	CriterionConnections   Criterion = "connections"
+	CriterionDepartureTime Criterion = "departure_time"
+	CriterionEmissions     Criterion = "emissions"
+	CriterionBaggage       Criterion = "baggage"
+	CriterionFlexibility   Criterion = "flexibility"
+)
+
+// DefaultScoreWeights favours price, followed by duration and connections.
+var DefaultScoreWeights = ScoreWeights{
+	CriterionPrice:         4,
+	CriterionDuration:      2,
+	CriterionConnections:   2,
+	CriterionDepartureTime: 1,
+	CriterionEmissions:     0.5,
+	CriterionBaggage:       1,
+	CriterionFlexibility:   1,
+}
+
+// criteria lists every criterion in the order components are reported.
+var criteria = []Criterion{
+	CriterionPrice,
+	CriterionDuration,
+	CriterionConnections,
+	CriterionDepartureTime,
+	CriterionEmissions,
+	CriterionBaggage,
+	CriterionFlexibility,
+}
+
+// lowerIsBetter reports whether a lower value of the criterion is preferable.
+func (c Criterion) lowerIsBetter() bool {
+	switch c {
+	case CriterionPrice, CriterionDuration, CriterionConnections, CriterionEmissions:
+		return true
+	}
+	return false
+}
+
+// WithScoreWeights replaces the default weights.
+func WithScoreWeights(weights ScoreWeights) OfferScorerOption {
+	return func(s *OfferScorer) {
+		s.weights = weights
+	}
+}
+
+// WithDepartureWindows sets the preferred departure times, one per slice.
+// Slices without a window are not scored on departure time.
+func WithDepartureWindows(windows ...DepartureWindow) OfferScorerOption {
+	return func(s *OfferScorer) {
+		s.departureWindows = windows
+	}
+}
+
+// NewOfferScorer returns a scorer using DefaultScoreWeights unless configured otherwise.
+func NewOfferScorer(opts ...OfferScorerOption) *OfferScorer {
+	s := &OfferScorer{
+		weights: DefaultScoreWeights,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Rank scores the offers and returns them best first.
+// All offers must be priced in the same currency.
+func (s *OfferScorer) Rank(offers []*Offer) ([]*ScoredOffer, error) {
+	if err := sameCurrency(offers); err != nil {
+		return nil, err
+	}
+
+	values := make(map[Criterion][]float64, len(criteria))
+	for _, c := range criteria {
+		if s.weight(c) == 0 {
+			continue
+		}
+		values[c] = make([]float64, len(offers))
+		for i, offer := range offers {
+			values[c][i] = s.value(c, offer)
+		}
+	}
+
+	scored := make([]*ScoredOffer, len(offers))
+	for i, offer := range offers {
+		so := &ScoredOffer{Offer: offer}
+
+		var total, weights float64
+		for _, c := range criteria {
+			weight := s.weight(c)
+			if weight == 0 {
+				continue
+			}
+
+			score := s.normalize(c, values[c][i], values[c])
+			so.Components = append(so.Components, ScoreComponent{
+				Criterion: c,
+				Value:     values[c][i],
+				Score:     score,
+				Weight:    weight,
+				Reason:    explain(c, values[c][i], values[c], offer),
+			})
+			total += score * weight
+			weights += weight
+		}
+		if weights > 0 {
+			so.Score = total / weights
+		}
+		scored[i] = so
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].Score > scored[j].Score
+	})
+	return scored, nil
+}
+
+// Highlights returns the cheapest, fastest and best scoring offers.
+// All offers must be priced in the same currency.
+func (s *OfferScorer) Highlights(offers []*Offer) (OfferHighlights, error) {
+	ranked, err := s.Rank(offers)
+	if err != nil || len(ranked) == 0 {
+		return OfferHighlights{}, err
+	}
+
+	sorted := append([]*Offer(nil), offers...)
+	SortOffers(sorted, ByTotalAmount, ByDuration)
+	cheapest := sorted[0]
+	SortOffers(sorted, ByDuration, ByTotalAmount)
+	fastest := sorted[0]
+
+	return OfferHighlights{
+		Cheapest: cheapest,
+		Fastest:  fastest,
+		Best:     ranked[0].Offer,
+	}, nil
+}
+
+// Explain returns one line per criterion describing how the offer was scored.
+func (so *ScoredOffer) Explain() []string {
+	lines := make([]string, len(so.Components))
+	for i, c := range so.Components {
+		lines[i] = fmt.Sprintf("%s: %s (score %.2f, weight %g)", c.Criterion, c.Reason, c.Score, c.Weight)
+	}
+	return lines
+}
+
+// ParetoFront returns the offers that no other offer beats on every one of the
+// criteria, i.e. those that are worth considering whatever the trade-off between
+// them. It defaults to price, duration and connections.
+//
+// When ranking on price, offers are only compared with offers in the same currency,
+// so the front has the best offers in each currency.
+func ParetoFront(offers []*Offer, by ...Criterion) []*Offer {
+	if len(by) == 0 {
+		by = []Criterion{CriterionPrice, CriterionDuration, CriterionConnections}
+	}
+	byPrice := false
+	for _, c := range by {
+		byPrice = byPrice || c == CriterionPrice
+	}
+
+	scorer := NewOfferScorer()
+	values := make([][]float64, len(offers))
+	for i, offer := range offers {
+		values[i] = make([]float64, len(by))
+		for j, c := range by {
+			v := scorer.value(c, offer)
+			if !c.lowerIsBetter() {
+				v = -v
+			}
+			if math.IsNaN(v) {
+				v = math.Inf(1)
+			}
+			values[i][j] = v
+		}
+	}
+
+	front := make([]*Offer, 0)
+	for i, offer := range offers {
+		dominated := false
+		for j := range offers {
+			if byPrice && offers[i].RawTotalCurrency != offers[j].RawTotalCurrency {
+				continue
+			}
+			if i != j && dominates(values[j], values[i]) {
+				dominated = true
+				break
+			}
+		}
+		if !dominated {
+			front = append(front, offer)
+		}
+	}
+	return front
+}
+
+// dominates reports whether a is at least as good as b on every value and better on at least one.
+func dominates(a, b []float64) bool {
+	better := false
+	for k := range a {
+		if a[k] > b[k] {
+			return false
+		}
+		if a[k] < b[k] {
+			better = true
+		}
+	}
+	return better
+}
+
+// SortOffers sorts offers by the comparators in turn, using each one to break ties in the one before.
+//
+//	duffel.SortOffers(offers, duffel.ByConnections, duffel.ByTotalAmount)
+func SortOffers(offers []*Offer, keys ...OfferComparator) {
+	sort.SliceStable(offers, func(i, j int) bool {
+		for _, cmp := range keys {
+			if c := cmp(offers[i], offers[j]); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+}
+
+// Descending reverses the order of a comparator.
+func Descending(cmp OfferComparator) OfferComparator {
+	return func(a, b *Offer) int {
+		return cmp(b, a)
+	}
+}
+
+// ByTotalAmount orders offers cheapest first. Offers in different
+// currencies are ordered by currency code.
+func ByTotalAmount(a, b *Offer) int {
+	return compareAmounts(a.TotalAmount(), b.TotalAmount())
+}
+
+// ByDuration orders offers by their total travel time, shortest first.
+func ByDuration(a, b *Offer) int {
+	return compareFloats(offerDuration(a).Minutes(), offerDuration(b).Minutes())
+}
+
+// ByConnections orders offers by their number of connections, fewest first.
+func ByConnections(a, b *Offer) int {
+	return compareFloats(float64(offerConnections(a)), float64(offerConnections(b)))
+}
+
+// ByEmissions orders offers by their total emissions, lowest first.
+// Offers with unknown emissions come last.
+func ByEmissions(a, b *Offer) int {
+	return compareFloats(offerEmissions(a), offerEmissions(b))
+}
+
+// ByDepartureTime orders offers by the departure time of their first slice, earliest first.
+func ByDepartureTime(a, b *Offer) int {
+	return compareFloats(offerDeparture(a), offerDeparture(b))
+}
+
+// weight returns the weight of the criterion. Departure time isn't scored
+// without preferred departure windows, as there is nothing to score it against.
+func (s *OfferScorer) weight(c Criterion) float64 {
+	if c == CriterionDepartureTime && len(s.departureWindows) == 0 {
+		return 0
+	}
+	return s.weights[c]
+}
+
+func (s *OfferScorer) value(c Criterion, offer *Offer) float64 {
+	switch c {
+	case CriterionPrice:
+		return amountValue(offer.TotalAmount())
+	case CriterionDuration:
+		return offerDuration(offer).Minutes()
+	case CriterionConnections:
+		return float64(offerConnections(offer))
+	case CriterionDepartureTime:
+		return s.departureFit(offer)
+	case CriterionEmissions:
+		return offerEmissions(offer)
+	case CriterionBaggage:
+		return includedCheckedBags(offer)
+	case CriterionFlexibility:
+		return flexibility(offer.Conditions)
+	}
+	return math.NaN()
+}
+
+// normalize scores v from 0 to 1 relative to the other values.
+// Unknown values score 0.5 so that they neither help nor hurt much.
+func (s *OfferScorer) normalize(c Criterion, v float64, all []float64) float64 {
+	if math.IsNaN(v) {
+		return 0.5
+	}
+	if c == CriterionDepartureTime || c == CriterionFlexibility {
+		// These are already scored from 0 to 1 on an absolute scale.
+		return v
+	}
+
+	lo, hi := bounds(all)
+	if hi == lo {
+		return 1
+	}
+	if c.lowerIsBetter() {
+		return (hi - v) / (hi - lo)
+	}
+	return (v - lo) / (hi - lo)
+}
+
+// departureFit scores how well the offer's departure times match the preferred windows.
+// Departures inside a window score 1, falling to 0 for departures 6 hours or more outside it.
+// It is NaN if none of the offer's slices have a window.
+func (s *OfferScorer) departureFit(offer *Offer) float64 {
+	var total float64
+	var n int
+	for i, slice := range offer.Slices {
+		if i >= len(s.departureWindows) || len(slice.Segments) == 0 {
+			continue
+		}
+		t, err := time.Parse(localTimeFormat, slice.Segments[0].RawDepartingAt)
+		if err != nil {
+			continue
+		}
+
+		w := s.departureWindows[i]
+		tod := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+		var off time.Duration
+		switch {
+		case tod < w.From:
+			off = w.From - tod
+		case tod > w.To:
+			off = tod - w.To
+		}
+		total += math.Max(0, 1-off.Hours()/6)
+		n++
+	}
+	if n == 0 {
+		return math.NaN()
+	}
+	return total / float64(n)
+}
+
+func explain(c Criterion, v float64, all []float64, offer *Offer) string {
+	if math.IsNaN(v) {
+		return "unknown"
+	}
+
+	lo, hi := bounds(all)
+	switch c {
+	case CriterionPrice:
+		total := offer.TotalAmount()
+		switch {
+		case v == lo:
+			return fmt.Sprintf("%s, the cheapest", total)
+		case lo <= 0:
+			// There is no percentage of a free offer, so give the difference instead.
+			diff, err := currency.NewAmount(strconv.FormatFloat(v-lo, 'f', -1, 64), total.CurrencyCode())
+			if err != nil {
+				return fmt.Sprintf("%s, more than the cheapest", total)
+			}
+			return fmt.Sprintf("%s, %s more than the cheapest", total, diff.Round())
+		}
+		return fmt.Sprintf("%s, %.0f%% more than the cheapest", total, (v-lo)/lo*100)
+	case CriterionDuration:
+		d := time.Duration(v) * time.Minute
+		if v == lo {
+			return fmt.Sprintf("%s, the fastest", d)
+		}
+		return fmt.Sprintf("%s, %s longer than the fastest", d, d-time.Duration(lo)*time.Minute)
+	case CriterionConnections:
+		switch v {
+		case 0:
+			return "direct"
+		case 1:
+			return "1 connection"
+		}
+		return fmt.Sprintf("%.0f connections", v)
+	case CriterionDepartureTime:
+		if v == 1 {
+			return "departs within the preferred times"
+		}
+		return "departs outside the preferred times"
+	case CriterionEmissions:
+		if v == lo {
+			return fmt.Sprintf("%.0fkg CO2, the lowest", v)
+		}
+		return fmt.Sprintf("%.0fkg CO2", v)
+	case CriterionBaggage:
+		if v == hi && v > 0 {
+			return fmt.Sprintf("%.1f checked bags per passenger, the most included", v)
+		}
+		return fmt.Sprintf("%.1f checked bags per passenger", v)
+	case CriterionFlexibility:
+		return describeFlexibility(offer.Conditions)
+	}
+	return ""
+}
+
+func describeFlexibility(c Conditions) string {
+	describe := func(name string, cond *ChangeCondition) string {
+		switch {
+		case cond == nil:
+			return name + " unknown"
+		case !cond.Allowed:
+			return "no " + name
+		case cond.RawPenaltyAmount != nil && *cond.RawPenaltyAmount != "" && *cond.RawPenaltyAmount != "0" && *cond.RawPenaltyAmount != "0.00":
+			return name + " with a penalty"
+		}
+		return "free " + name
+	}
+	return describe("refunds", c.RefundBeforeDeparture) + ", " + describe("changes", c.ChangeBeforeDeparture)
+}
+
+// flexibility scores the offer's conditions from 0 to 1. Refunds and changes each
+// count for half, or a quarter if they incur a penalty.
+func flexibility(c Conditions) float64 {
+	if c.RefundBeforeDeparture == nil && c.ChangeBeforeDeparture == nil {
+		return math.NaN()
+	}
+
+	score := func(cond *ChangeCondition) float64 {
+		if cond == nil || !cond.Allowed {
+			return 0
+		}
+		if cond.RawPenaltyAmount != nil {
+			if p, err := strconv.ParseFloat(*cond.RawPenaltyAmount, 64); err == nil && p > 0 {
+				return 0.25
+			}
+		}
+		return 0.5
+	}
+	return score(c.RefundBeforeDeparture) + score(c.ChangeBeforeDeparture)
+}
+
+// includedCheckedBags returns the average number of checked bags included per passenger per segment.
+func includedCheckedBags(offer *Offer) float64 {
+	var bags, n int
+	for _, slice := range offer.Slices {
+		for _, segment := range slice.Segments {
+			for _, p := range segment.Passengers {
+				checked, _ := countBags(p.Baggages)
+				bags += checked
+				n++
+			}
+		}
+	}
+	if n == 0 {
+		return math.NaN()
+	}
+	return float64(bags) / float64(n)
+}
+
+func offerDuration(offer *Offer) time.Duration {
+	var total time.Duration
+	for _, slice := range offer.Slices {
+		if slice.Duration != 0 {
+			total += time.Duration(slice.Duration)
+			continue
+		}
+		for _, segment := range slice.Segments {
+			total += time.Duration(segment.Duration)
+		}
+	}
+	return total
+}
+
+func offerConnections(offer *Offer) int {
+	connections := 0
+	for _, slice := range offer.Slices {
+		if len(slice.Segments) > 1 {
+			connections += len(slice.Segments) - 1
+		}
+	}
+	return connections
+}
+
+func offerEmissions(offer *Offer) float64 {
+	v, err := strconv.ParseFloat(offer.TotalEmissionsKg, 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return v
+}
+
+// offerDeparture returns the departure time of the offer's first segment
+// as a Unix timestamp of its local time.
+func offerDeparture(offer *Offer) float64 {
+	if len(offer.Slices) == 0 || len(offer.Slices[0].Segments) == 0 {
+		return math.NaN()
+	}
+	t, err := time.Parse(localTimeFormat, offer.Slices[0].Segments[0].RawDepartingAt)
+	if err != nil {
+		return math.NaN()
+	}
+	return float64(t.Unix())
+}
+
+func sameCurrency(offers []*Offer) error {
+	for _, offer := range offers {
+		if offer.RawTotalCurrency != offers[0].RawTotalCurrency {
+			return fmt.Errorf("cannot compare offers priced in %s and %s", offers[0].RawTotalCurrency, offer.RawTotalCurrency)
+		}
+	}
+	return nil
+}
+
+func amountValue(a currency.Amount) float64 {
+	v, err := strconv.ParseFloat(a.Number(), 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return v
+}
+
+func compareAmounts(a, b currency.Amount) int {
+	cmp, err := a.Cmp(b)
+	if err != nil {
+		return strings.Compare(a.CurrencyCode(), b.CurrencyCode())
+	}
+	return cmp
+}
+
+// compareFloats orders unknown (NaN) values last.
+func compareFloats(a, b float64) int {
+	switch {
+	case math.IsNaN(a) && math.IsNaN(b):
+		return 0
+	case math.IsNaN(a):
+		return 1
+	case math.IsNaN(b):
+		return -1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// bounds returns the lowest and highest known values.
+func bounds(values []float64) (lo, hi float64) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	return lo, hi
+}
@@ -0,0 +1,128 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRankedOffer(id, amount string, duration time.Duration, segments int, departing string) *Offer {
+	slice := Slice{Duration: Duration(duration)}
+	for i := 0; i < segments; i++ {
+		slice.Segments = append(slice.Segments, Flight{
+			RawDepartingAt: departing,
+			Passengers:     []SegmentPassenger{{Baggages: []Baggage{{Type: "checked", Quantity: 1}}}},
+		})
+	}
+	return &Offer{
+		ID:               id,
+		RawTotalAmount:   amount,
+		RawTotalCurrency: "GBP",
+		TotalEmissionsKg: "400",
+		Slices:           []Slice{slice},
+	}
+}
+
+func TestOfferScorerRank(t *testing.T) {
+	a := assert.New(t)
+
+	cheap := testRankedOffer("off_cheap", "300.00", 14*time.Hour, 3, "2024-03-10T06:00:00")
+	fast := testRankedOffer("off_fast", "600.00", 7*time.Hour, 1, "2024-03-10T09:00:00")
+	balanced := testRankedOffer("off_balanced", "350.00", 8*time.Hour, 1, "2024-03-10T10:00:00")
+	worse := testRankedOffer("off_worse", "650.00", 15*time.Hour, 3, "2024-03-10T10:00:00")
+
+	scorer := NewOfferScorer(WithDepartureWindows(DepartureWindow{From: 8 * time.Hour, To: 12 * time.Hour}))
+	ranked, err := scorer.Rank([]*Offer{cheap, fast, balanced, worse})
+	a.NoError(err)
+	a.Len(ranked, 4)
+	a.Equal("off_balanced", ranked[0].Offer.ID)
+	a.Equal("off_worse", ranked[3].Offer.ID)
+	a.InDelta(0, ranked[3].Components[0].Score, 0.001)
+
+	explanation := ranked[0].Explain()
+	a.Len(explanation, len(criteria))
+	a.Equal("price: 350.00 GBP, 17% more than the cheapest (score 0.86, weight 4)", explanation[0])
+	a.Equal("connections: direct (score 1.00, weight 2)", explanation[2])
+	a.Equal("flexibility: unknown (score 0.50, weight 1)", explanation[6])
+
+	highlights, err := scorer.Highlights([]*Offer{cheap, fast, balanced, worse})
+	a.NoError(err)
+	a.Equal("off_cheap", highlights.Cheapest.ID)
+	a.Equal("off_fast", highlights.Fastest.ID)
+	a.Equal("off_balanced", highlights.Best.ID)
+
+	usd := testRankedOffer("off_usd", "100.00", time.Hour, 1, "2024-03-10T10:00:00")
+	usd.RawTotalCurrency = "USD"
+	_, err = scorer.Rank([]*Offer{cheap, usd})
+	a.Error(err)
+}
+
+func TestOfferScorerRankWithoutDepartureWindows(t *testing.T) {
+	a := assert.New(t)
+
+	free := testRankedOffer("off_free", "0.00", 8*time.Hour, 1, "2024-03-10T06:00:00")
+	paid := testRankedOffer("off_paid", "120.00", 8*time.Hour, 1, "2024-03-10T10:00:00")
+
+	ranked, err := NewOfferScorer().Rank([]*Offer{free, paid})
+	a.NoError(err)
+
+	// Departure time isn't scored without preferred windows.
+	for _, c := range ranked[0].Components {
+		a.NotEqual(CriterionDepartureTime, c.Criterion)
+	}
+	a.Len(ranked[0].Components, len(criteria)-1)
+
+	a.Equal("0.00 GBP, the cheapest", ranked[0].Components[0].Reason)
+	a.Equal("120.00 GBP, 120.00 GBP more than the cheapest", ranked[1].Components[0].Reason)
+}
+
+func TestParetoFront(t *testing.T) {
+	a := assert.New(t)
+
+	cheap := testRankedOffer("off_cheap", "300.00", 14*time.Hour, 3, "2024-03-10T06:00:00")
+	fast := testRankedOffer("off_fast", "600.00", 7*time.Hour, 1, "2024-03-10T09:00:00")
+	balanced := testRankedOffer("off_balanced", "350.00", 8*time.Hour, 1, "2024-03-10T10:00:00")
+	worse := testRankedOffer("off_worse", "650.00", 15*time.Hour, 3, "2024-03-10T10:00:00")
+
+	front := ParetoFront([]*Offer{cheap, fast, balanced, worse})
+	a.Len(front, 3)
+	a.NotContains(front, worse)
+
+	front = ParetoFront([]*Offer{cheap, fast, balanced, worse}, CriterionPrice)
+	a.Equal([]*Offer{cheap}, front)
+
+	// Offers in another currency are compared among themselves.
+	usd := testRankedOffer("off_usd", "900.00", 20*time.Hour, 3, "2024-03-10T10:00:00")
+	usd.RawTotalCurrency = "USD"
+	front = ParetoFront([]*Offer{cheap, worse, usd}, CriterionPrice)
+	a.Equal([]*Offer{cheap, usd}, front)
+}
+
+func TestSortOffers(t *testing.T) {
+	a := assert.New(t)
+
+	first := testRankedOffer("off_1", "300.00", 8*time.Hour, 1, "2024-03-10T06:00:00")
+	second := testRankedOffer("off_2", "300.00", 7*time.Hour, 1, "2024-03-10T09:00:00")
+	third := testRankedOffer("off_3", "200.00", 9*time.Hour, 2, "2024-03-10T10:00:00")
+
+	offers := []*Offer{first, second, third}
+	SortOffers(offers, ByConnections, ByTotalAmount, ByDuration)
+	a.Equal([]*Offer{second, first, third}, offers)
+
+	SortOffers(offers, Descending(ByDepartureTime))
+	a.Equal([]*Offer{third, second, first}, offers)
+
+	// Offers in different currencies are ordered consistently by currency code.
+	usd := Offer{RawTotalAmount: "100.00", RawTotalCurrency: "USD"}
+	gbp := Offer{RawTotalAmount: "500.00", RawTotalCurrency: "GBP"}
+	list := Offers{usd, gbp}
+	sort.Sort(list)
+	a.Equal("GBP", list[0].RawTotalCurrency)
+	a.Len(list.Pointers(), 2)
+}
@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"strconv"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 type (
@@ -48,3 +50,26 @@ func parseRateLimit(resp *http.Response) (*RateLimit, error) {
 
 	return rl, nil
 }
+
+// rate returns the rate at which requests can be made to stay within the limit,
+// which spreads the remaining requests over the time left until the limit resets.
+func (rl *RateLimit) rate() rate.Limit {
+	switch {
+	case rl.Period <= 0:
+		// The limit has already reset.
+		return rate.Inf
+	case rl.Remaining <= 0:
+		// Nothing is left, so wait for the reset.
+		return rate.Every(rl.Period)
+	}
+	return rate.Limit(float64(rl.Remaining) / rl.Period.Seconds())
+}
+
+// burst returns how many requests can be made at once, which is no more than
+// the number remaining.
+func (rl *RateLimit) burst() int {
+	if rl.Remaining < 1 {
+		return 1
+	}
+	return rl.Remaining
+}
@@ -0,0 +1,33 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestRateLimitRate(t *testing.T) {
+	a := assert.New(t)
+
+	// The remaining requests are spread over the time left, not the whole limit.
+	rl := &RateLimit{Limit: 60, Remaining: 5, Period: 2 * time.Second}
+	a.Equal(rate.Limit(2.5), rl.rate())
+	a.Equal(5, rl.burst())
+
+	rl = &RateLimit{Limit: 60, Remaining: 60, Period: 60 * time.Second}
+	a.Equal(rate.Limit(1), rl.rate())
+	a.Equal(60, rl.burst())
+
+	rl = &RateLimit{Limit: 60, Remaining: 0, Period: 4 * time.Second}
+	a.Equal(rate.Every(4*time.Second), rl.rate())
+	a.Equal(1, rl.burst())
+
+	rl = &RateLimit{Limit: 60, Remaining: 5, Period: 0}
+	a.Equal(rate.Inf, rl.rate())
+}
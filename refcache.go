@@ -0,0 +1,416 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/encoding/json"
+)
+
+const defaultReferenceTTL = 24 * time.Hour
+
+type (
+	// ReferenceClient is the set of clients for reference data that barely changes.
+	ReferenceClient interface {
+		AirportsClient
+		AirlinesClient
+		AircraftClient
+		PlacesClient
+	}
+
+	// ReferenceCache keeps airports, airlines, aircraft and cities in memory.
+	//
+	// It implements ReferenceClient, serving Get requests from memory and falling back
+	// to the wrapped client for records it doesn't know about. List requests and place
+	// suggestions are passed through to the wrapped client.
+	ReferenceCache struct {
+		client       ReferenceClient
+		ttl          time.Duration
+		snapshotPath string
+
+		mu   sync.RWMutex
+		data *referenceIndex
+		// refreshMu ensures only one refresh runs at a time.
+		refreshMu sync.Mutex
+		lastErr   error
+	}
+
+	// ReferenceCacheOption configures a ReferenceCache.
+	ReferenceCacheOption func(*ReferenceCache)
+
+	// ReferenceSnapshot is the reference data persisted to disk.
+	ReferenceSnapshot struct {
+		LoadedAt time.Time   `json:"loaded_at"`
+		Airports []*Airport  `json:"airports"`
+		Airlines []*Airline  `json:"airlines"`
+		Aircraft []*Aircraft `json:"aircraft"`
+		Cities   []*City     `json:"cities"`
+	}
+
+	referenceIndex struct {
+		snapshot ReferenceSnapshot
+
+		airportsByID   map[string]*Airport
+		airportsByIATA map[string]*Airport
+		airportsByICAO map[string]*Airport
+		airlinesByID   map[string]*Airline
+		airlinesByIATA map[string]*Airline
+		aircraftByID   map[string]*Aircraft
+		aircraftByIATA map[string]*Aircraft
+		citiesByID     map[string]*City
+		citiesByIATA   map[string]*City
+
+		airportsByLocation *AirportIndex
+	}
+)
+
+// WithReferenceTTL sets how long loaded reference data is considered fresh. Defaults to 24 hours.
+func WithReferenceTTL(ttl time.Duration) ReferenceCacheOption {
+	return func(c *ReferenceCache) {
+		c.ttl = ttl
+	}
+}
+
+// WithReferenceSnapshot persists the reference data as JSON to the file at path,
+// and loads it from there on startup while it is still fresh.
+func WithReferenceSnapshot(path string) ReferenceCacheOption {
+	return func(c *ReferenceCache) {
+		c.snapshotPath = path
+	}
+}
+
+// NewReferenceCache returns a ReferenceCache wrapping the given client.
+// Call Load before use to populate it.
+func NewReferenceCache(client ReferenceClient, opts ...ReferenceCacheOption) *ReferenceCache {
+	c := &ReferenceCache{
+		client: client,
+		ttl:    defaultReferenceTTL,
+		data:   newReferenceIndex(ReferenceSnapshot{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Load populates the cache from the snapshot file if it is still fresh,
+// otherwise it refreshes the data from the API. A stale snapshot is still
+// loaded first, so that the cache can be used if the refresh fails, in which
+// case the refresh error is returned.
+func (c *ReferenceCache) Load(ctx context.Context) error {
+	if c.snapshotPath != "" {
+		snapshot, err := readReferenceSnapshot(c.snapshotPath)
+		if err == nil {
+			c.set(snapshot)
+			if time.Since(snapshot.LoadedAt) < c.ttl {
+				return nil
+			}
+		}
+	}
+
+	return c.Refresh(ctx)
+}
+
+// Refresh loads the full lists of reference data from the API, replacing the
+// cached data and writing a new snapshot if one is configured.
+// If the refresh fails the existing data is kept.
+func (c *ReferenceCache) Refresh(ctx context.Context) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	err := c.refresh(ctx)
+
+	c.mu.Lock()
+	c.lastErr = err
+	c.mu.Unlock()
+
+	return err
+}
+
+func (c *ReferenceCache) refresh(ctx context.Context) error {
+	var (
+		snapshot = ReferenceSnapshot{LoadedAt: time.Now()}
+		err      error
+	)
+
+	if snapshot.Airports, err = Collect(c.client.ListAirports(ctx, ListAirportsParams{ListOptions: ListOptions{Limit: maxListLimit}})); err != nil {
+		return err
+	}
+	if snapshot.Airlines, err = Collect(c.client.ListAirlines(ctx, ListAirlinesParams{ListOptions: ListOptions{Limit: maxListLimit}})); err != nil {
+		return err
+	}
+	if snapshot.Aircraft, err = Collect(c.client.ListAircraft(ctx, ListAircraftParams{ListOptions: ListOptions{Limit: maxListLimit}})); err != nil {
+		return err
+	}
+	if snapshot.Cities, err = Collect(c.client.Cities(ctx, ListCitiesParams{ListOptions: ListOptions{Limit: maxListLimit}})); err != nil {
+		return err
+	}
+
+	c.set(snapshot)
+
+	if c.snapshotPath != "" {
+		return writeReferenceSnapshot(c.snapshotPath, snapshot)
+	}
+	return nil
+}
+
+// Start refreshes the cache in the background each time the TTL elapses, until ctx is cancelled.
+// Failed refreshes keep the existing data and are reported by LastError.
+func (c *ReferenceCache) Start(ctx context.Context) {
+	go func() {
+		for {
+			wait := time.Until(c.LoadedAt().Add(c.ttl))
+			if wait < 0 {
+				wait = 0
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+				if err := c.Refresh(ctx); err != nil {
+					// Back off before retrying a failed refresh.
+					select {
+					case <-ctx.Done():
+						return
+					case <-time.After(time.Minute):
+					}
+				}
+			}
+		}
+	}()
+}
+
+// LoadedAt returns when the cached data was loaded from the API.
+func (c *ReferenceCache) LoadedAt() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.data.snapshot.LoadedAt
+}
+
+// LastError returns the error from the most recent refresh, if any.
+func (c *ReferenceCache) LastError() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastErr
+}
+
+// Snapshot returns the cached reference data.
+func (c *ReferenceCache) Snapshot() ReferenceSnapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.data.snapshot
+}
+
+// Airport looks up an airport by its Duffel ID.
+func (c *ReferenceCache) Airport(id string) (*Airport, bool) {
+	return lookup(c, func(d *referenceIndex) map[string]*Airport { return d.airportsByID }, id)
+}
+
+// AirportByIATA looks up an airport by its IATA code, e.g. "LHR".
+func (c *ReferenceCache) AirportByIATA(code string) (*Airport, bool) {
+	return lookup(c, func(d *referenceIndex) map[string]*Airport { return d.airportsByIATA }, strings.ToUpper(code))
+}
+
+// AirportByICAO looks up an airport by its ICAO code, e.g. "EGLL".
+func (c *ReferenceCache) AirportByICAO(code string) (*Airport, bool) {
+	return lookup(c, func(d *referenceIndex) map[string]*Airport { return d.airportsByICAO }, strings.ToUpper(code))
+}
+
+// Airline looks up an airline by its Duffel ID.
+func (c *ReferenceCache) Airline(id string) (*Airline, bool) {
+	return lookup(c, func(d *referenceIndex) map[string]*Airline { return d.airlinesByID }, id)
+}
+
+// AirlineByIATA looks up an airline by its IATA code, e.g. "BA".
+func (c *ReferenceCache) AirlineByIATA(code string) (*Airline, bool) {
+	return lookup(c, func(d *referenceIndex) map[string]*Airline { return d.airlinesByIATA }, strings.ToUpper(code))
+}
+
+// Aircraft looks up an aircraft by its Duffel ID.
+func (c *ReferenceCache) Aircraft(id string) (*Aircraft, bool) {
+	return lookup(c, func(d *referenceIndex) map[string]*Aircraft { return d.aircraftByID }, id)
+}
+
+// AircraftByIATA looks up an aircraft by its IATA code, e.g. "380".
+func (c *ReferenceCache) AircraftByIATA(code string) (*Aircraft, bool) {
+	return lookup(c, func(d *referenceIndex) map[string]*Aircraft { return d.aircraftByIATA }, strings.ToUpper(code))
+}
+
+// CityByID looks up a city by its Duffel ID.
+func (c *ReferenceCache) CityByID(id string) (*City, bool) {
+	return lookup(c, func(d *referenceIndex) map[string]*City { return d.citiesByID }, id)
+}
+
+// CityByIATA looks up a city by its IATA code, e.g. "LON".
+func (c *ReferenceCache) CityByIATA(code string) (*City, bool) {
+	return lookup(c, func(d *referenceIndex) map[string]*City { return d.citiesByIATA }, strings.ToUpper(code))
+}
+
+// NearestAirports returns the cached airports within radiusKm of the point, nearest first.
+// At most limit airports are returned, or all of them if limit is 0.
+func (c *ReferenceCache) NearestAirports(lat, lon, radiusKm float64, limit int) []AirportDistance {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.data.airportsByLocation.Nearest(lat, lon, radiusKm, limit)
+}
+
+// AirportsWithin returns the cached airports inside the bounding box.
+func (c *ReferenceCache) AirportsWithin(box BoundingBox) []*Airport {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.data.airportsByLocation.WithinBounds(box)
+}
+
+func (c *ReferenceCache) ListAirports(ctx context.Context, params ...ListAirportsParams) *Iter[Airport] {
+	return c.client.ListAirports(ctx, params...)
+}
+
+func (c *ReferenceCache) GetAirport(ctx context.Context, id string) (*Airport, error) {
+	if airport, ok := c.Airport(id); ok {
+		return airport, nil
+	}
+	return c.client.GetAirport(ctx, id)
+}
+
+func (c *ReferenceCache) ListAirlines(ctx context.Context, params ...ListAirlinesParams) *Iter[Airline] {
+	return c.client.ListAirlines(ctx, params...)
+}
+
+func (c *ReferenceCache) GetAirline(ctx context.Context, id string) (*Airline, error) {
+	if airline, ok := c.Airline(id); ok {
+		return airline, nil
+	}
+	return c.client.GetAirline(ctx, id)
+}
+
+func (c *ReferenceCache) ListAircraft(ctx context.Context, params ...ListAircraftParams) *Iter[Aircraft] {
+	return c.client.ListAircraft(ctx, params...)
+}
+
+func (c *ReferenceCache) GetAircraft(ctx context.Context, id string) (*Aircraft, error) {
+	if aircraft, ok := c.Aircraft(id); ok {
+		return aircraft, nil
+	}
+	return c.client.GetAircraft(ctx, id)
+}
+
+func (c *ReferenceCache) PlaceSuggestions(ctx context.Context, query string, params ...PlaceSuggestionsParams) ([]*Place, error) {
+	return c.client.PlaceSuggestions(ctx, query, params...)
+}
+
+func (c *ReferenceCache) Cities(ctx context.Context, params ...ListCitiesParams) *Iter[City] {
+	return c.client.Cities(ctx, params...)
+}
+
+func (c *ReferenceCache) City(ctx context.Context, id string) (*City, error) {
+	if city, ok := c.CityByID(id); ok {
+		return city, nil
+	}
+	return c.client.City(ctx, id)
+}
+
+func (c *ReferenceCache) set(snapshot ReferenceSnapshot) {
+	data := newReferenceIndex(snapshot)
+
+	c.mu.Lock()
+	c.data = data
+	c.mu.Unlock()
+}
+
+func lookup[T any](c *ReferenceCache, index func(*referenceIndex) map[string]*T, key string) (*T, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	v, ok := index(c.data)[key]
+	return v, ok
+}
+
+func newReferenceIndex(snapshot ReferenceSnapshot) *referenceIndex {
+	d := &referenceIndex{
+		snapshot:       snapshot,
+		airportsByID:   make(map[string]*Airport, len(snapshot.Airports)),
+		airportsByIATA: make(map[string]*Airport, len(snapshot.Airports)),
+		airportsByICAO: make(map[string]*Airport, len(snapshot.Airports)),
+		airlinesByID:   make(map[string]*Airline, len(snapshot.Airlines)),
+		airlinesByIATA: make(map[string]*Airline, len(snapshot.Airlines)),
+		aircraftByID:   make(map[string]*Aircraft, len(snapshot.Aircraft)),
+		aircraftByIATA: make(map[string]*Aircraft, len(snapshot.Aircraft)),
+		citiesByID:     make(map[string]*City, len(snapshot.Cities)),
+		citiesByIATA:   make(map[string]*City, len(snapshot.Cities)),
+
+		airportsByLocation: NewAirportIndex(snapshot.Airports),
+	}
+
+	for _, a := range snapshot.Airports {
+		d.airportsByID[a.ID] = a
+		indexCode(d.airportsByIATA, a.IATACode, a)
+		indexCode(d.airportsByICAO, a.ICAOCode, a)
+	}
+	for _, a := range snapshot.Airlines {
+		d.airlinesByID[a.ID] = a
+		indexCode(d.airlinesByIATA, a.IATACode, a)
+	}
+	for _, a := range snapshot.Aircraft {
+		d.aircraftByID[a.ID] = a
+		indexCode(d.aircraftByIATA, a.IATACode, a)
+	}
+	for _, city := range snapshot.Cities {
+		d.citiesByID[city.ID] = city
+		indexCode(d.citiesByIATA, city.IATACode, city)
+	}
+
+	return d
+}
+
+func indexCode[T any](index map[string]*T, code string, v *T) {
+	if code == "" {
+		return
+	}
+	code = strings.ToUpper(code)
+	if _, exists := index[code]; !exists {
+		index[code] = v
+	}
+}
+
+func readReferenceSnapshot(path string) (ReferenceSnapshot, error) {
+	var snapshot ReferenceSnapshot
+
+	f, err := os.Open(path)
+	if err != nil {
+		return snapshot, err
+	}
+	defer f.Close()
+
+	err = json.NewDecoder(f).Decode(&snapshot)
+	return snapshot, err
+}
+
+// writeReferenceSnapshot writes the snapshot to a temporary file first,
+// so that a failed write never leaves a truncated snapshot behind.
+func writeReferenceSnapshot(path string, snapshot ReferenceSnapshot) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := json.NewEncoder(f).Encode(snapshot); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+var _ ReferenceClient = (*ReferenceCache)(nil)
@@ -0,0 +1,127 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func mockReferenceLists() {
+	gock.New("https://api.duffel.com").
+		Get("/air/cities").
+		MatchParam("limit", "200").
+		Reply(200).
+		SetHeader("Ratelimit-Limit", "5").
+		SetHeader("Ratelimit-Remaining", "5").
+		SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+		SetHeader("Date", time.Now().Format(time.RFC1123)).
+		File("fixtures/200-list-cities.json")
+
+	// These fixtures have a second page, which is empty.
+	for path, fixture := range map[string]string{
+		"/air/airports": "fixtures/200-list-airports.json",
+		"/air/airlines": "fixtures/200-list-airlines.json",
+		"/air/aircraft": "fixtures/200-list-aircraft.json",
+	} {
+		gock.New("https://api.duffel.com").
+			Get(path).
+			MatchParam("after", "g2wAAAACbQAAABBBZXJvbWlzdC1LaGFya2l2bQAAAB=").
+			Reply(200).
+			SetHeader("Ratelimit-Limit", "5").
+			SetHeader("Ratelimit-Remaining", "5").
+			SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+			SetHeader("Date", time.Now().Format(time.RFC1123)).
+			File("fixtures/200-list-empty.json")
+
+		gock.New("https://api.duffel.com").
+			Get(path).
+			MatchParam("limit", "200").
+			Reply(200).
+			SetHeader("Ratelimit-Limit", "5").
+			SetHeader("Ratelimit-Remaining", "5").
+			SetHeader("Ratelimit-Reset", time.Now().Format(time.RFC1123)).
+			SetHeader("Date", time.Now().Format(time.RFC1123)).
+			File(fixture)
+	}
+}
+
+func TestReferenceCache(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+	mockReferenceLists()
+
+	ctx := context.TODO()
+	snapshotPath := filepath.Join(t.TempDir(), "reference.json")
+	cache := NewReferenceCache(New("duffel_test_123"), WithReferenceSnapshot(snapshotPath))
+
+	a.NoError(cache.Load(ctx))
+	a.True(gock.IsDone())
+
+	airport, ok := cache.AirportByIATA("lhr")
+	a.True(ok)
+	a.Equal("arp_lhr_gb", airport.ID)
+
+	airport, ok = cache.AirportByICAO("EGLL")
+	a.True(ok)
+	a.Equal("Heathrow", airport.Name)
+
+	airline, err := cache.GetAirline(ctx, "aln_00001876aqC8c5umZmrRds")
+	a.NoError(err)
+	a.Equal("BA", airline.IATACode)
+
+	nearest := cache.NearestAirports(64.0, -142.0, 50, 1)
+	a.Len(nearest, 1)
+	a.Equal("arp_lhr_gb", nearest[0].Airport.ID)
+	a.Len(cache.AirportsWithin(BoundingBox{MinLatitude: 60, MinLongitude: -145, MaxLatitude: 65, MaxLongitude: -140}), 1)
+
+	aircraft, ok := cache.AircraftByIATA("380")
+	a.True(ok)
+	a.Equal("arc_00009UhD4ongolulWd91Ky", aircraft.ID)
+
+	city, err := cache.City(ctx, "cit_lon_gb")
+	a.NoError(err)
+	a.Equal("LON", city.IATACode)
+
+	// A fresh snapshot is loaded from disk without calling the API.
+	restored := NewReferenceCache(New("duffel_test_123"), WithReferenceSnapshot(snapshotPath))
+	a.NoError(restored.Load(ctx))
+	a.Equal(cache.LoadedAt().Unix(), restored.LoadedAt().Unix())
+
+	airline, ok = restored.AirlineByIATA("BA")
+	a.True(ok)
+	a.Equal("British Airways", airline.Name)
+}
+
+func TestReferenceCacheStaleSnapshot(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+
+	snapshotPath := filepath.Join(t.TempDir(), "reference.json")
+	err := writeReferenceSnapshot(snapshotPath, ReferenceSnapshot{
+		LoadedAt: time.Now().Add(-48 * time.Hour),
+		Airports: []*Airport{{ID: "arp_lhr_gb", IATACode: "LHR", Name: "Heathrow"}},
+	})
+	a.NoError(err)
+
+	// The refresh fails, because the API can't be reached.
+	gock.New("https://api.duffel.com").
+		Get("/air/airports").
+		ReplyError(errors.New("offline"))
+
+	cache := NewReferenceCache(New("duffel_test_123"), WithReferenceSnapshot(snapshotPath))
+	a.Error(cache.Load(context.TODO()))
+
+	// The stale snapshot is still used.
+	airport, ok := cache.AirportByIATA("LHR")
+	a.True(ok)
+	a.Equal("Heathrow", airport.Name)
+}
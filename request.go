@@ -8,9 +8,6 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"time"
-
-	"golang.org/x/time/rate"
 )
 
 func newInternalClient[Req any, Resp any](a *API) *client[Req, Resp] {
@@ -18,10 +15,10 @@ func newInternalClient[Req any, Resp any](a *API) *client[Req, Resp] {
 		httpDoer: a.httpDoer,
 		options:  a.options,
 		APIToken: a.APIToken,
-		limiter:  rate.NewLimiter(rate.Every(1*time.Second), 5),
+		limiter:  a.limiter,
 		afterResponse: []func(resp *http.Response){
 			func(resp *http.Response) {
-				a.lastRequestID = resp.Header.Get(RequestIDHeader)
+				a.setLastRequestID(resp.Header.Get(RequestIDHeader))
 			},
 		},
 	}
@@ -55,8 +52,8 @@ func (c *client[Req, Resp]) Do(ctx context.Context, resourceName string, method
 	}
 
 	c.rateLimit = rateLimit
-	c.limiter.SetBurst(rateLimit.Limit)
-	c.limiter.SetLimit(rate.Every(rateLimit.Period))
+	c.limiter.SetBurst(rateLimit.burst())
+	c.limiter.SetLimit(rateLimit.rate())
 
 	if rateLimit.Remaining == 0 || resp.StatusCode == http.StatusTooManyRequests {
 		return nil, fmt.Errorf("rate limit exceeded, reset in: %s, current limit: %d", rateLimit.Period.String(), rateLimit.Limit)
@@ -22,6 +22,7 @@ type RequestBuilder[Req any, Resp any] struct {
 	resourcePath   string
 	requestOptions []RequestOption
 	body           *Req
+	listOptions    ListOptions
 }
 
 type RequestMiddleware func(r *http.Request) error
@@ -111,7 +112,14 @@ func (r *RequestBuilder[Req, Resp]) WithParam(key, value string) *RequestBuilder
 
 // WithParams sets the URL query params for the request.
 // These operations will be applied in defined order after the request is initialized.
+//
+// Params that embed ListOptions also set the position that Iter starts from.
 func (r *RequestBuilder[Req, Resp]) WithParams(obj ...ParamEncoder[Req]) *RequestBuilder[Req, Resp] {
+	for _, o := range obj {
+		if lo, ok := o.(listOptions); ok {
+			r.listOptions = lo.getListOptions()
+		}
+	}
 	r.requestOptions = append(r.requestOptions, WithEncodableParams(obj...))
 	return r
 }
@@ -165,7 +173,7 @@ func (r *RequestBuilder[Req, Resp]) Patch(path string, body *Req, opts ...Reques
 
 // Iter finalizes the request and returns an iterator over the response.
 func (r *RequestBuilder[Req, Resp]) Iter(ctx context.Context) *Iter[Resp] {
-	return GetIter(func(lastMeta *ListMeta) (*List[Resp], error) {
+	return getIterContext(ctx, func(ctx context.Context, lastMeta *ListMeta) (*List[Resp], error) {
 		ctx, cancel := context.WithDeadline(ctx, time.Now().Add(90*time.Second))
 		defer cancel()
 
@@ -175,6 +183,18 @@ func (r *RequestBuilder[Req, Resp]) Iter(ctx context.Context) *Iter[Resp] {
 			return nil, errors.Wrap(err, "failed to make request")
 		}
 
+		if r.client.options.StreamingDecode {
+			stream, err := decodeResponseStream[Resp](response)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to decode response")
+			}
+
+			list.stream = stream
+			list.SetListMeta(stream.meta)
+			list.setRequestID(response.Header.Get(RequestIDHeader))
+			return list, nil
+		}
+
 		container := new(ResponsePayload[[]*Resp])
 		err = decodeResponse(response, &container)
 		if err != nil {
@@ -185,7 +205,7 @@ func (r *RequestBuilder[Req, Resp]) Iter(ctx context.Context) *Iter[Resp] {
 		list.SetItems(container.Data)
 		list.setRequestID(response.Header.Get(RequestIDHeader))
 		return list, nil
-	})
+	}, r.listOptions)
 }
 
 // Slice finalizes the request and returns the first page of items as a slice along with the error.
@@ -240,6 +260,16 @@ func decodeResponse[T any](resp *http.Response, v T) error {
 	return json.NewDecoder(reader).Decode(v)
 }
 
+func decodeResponseStream[T any](resp *http.Response) (*streamDecoder[T], error) {
+	reader, err := gzipResponseReader(resp)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return newStreamDecoder[T](reader)
+}
+
 // normalizeParams returns a slice of interfaces from the given params.
 // This is only neeeded because Go doesn't allow slice conversion of slice spreads.
 // See: https://github.com/golang/go/wiki/InterfaceSlice
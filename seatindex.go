@@ -0,0 +1,363 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/bojanz/currency"
+)
+
+type (
+	// SeatPosition is where a seat is within its row.
+	SeatPosition string
+
+	// SeatIndex indexes the seats of a seatmap by designator, along with where
+	// each seat is and who it is available to.
+	SeatIndex struct {
+		Seatmap *Seatmap
+
+		seats        []*SeatInfo
+		byDesignator map[string]*SeatInfo
+	}
+
+	// SeatInfo is a seat along with its position in the cabin.
+	SeatInfo struct {
+		Element *SectionElement
+		Cabin   *Cabin
+
+		Designator string
+		// RowNumber and Column are parsed from the designator, e.g. 12 and "C" for "12C".
+		RowNumber int
+		Column    string
+		Position  SeatPosition
+
+		// RowIndex, SectionIndex and ElementIndex locate the seat within Cabin.Rows.
+		RowIndex     int
+		SectionIndex int
+		ElementIndex int
+
+		// ExitRow is true if the seat is in a row next to an exit.
+		ExitRow bool
+		// OverWing is true if the seat is in a row over the wings.
+		OverWing bool
+		// NearBassinet is true if the seat is in a section with a bassinet,
+		// either in the same row or in front of it.
+		NearBassinet bool
+	}
+
+	// SeatMatcher reports whether a seat matches a condition.
+	SeatMatcher func(*SeatInfo) bool
+)
+
+const (
+	SeatPositionWindow SeatPosition = "window"
+	SeatPositionAisle  SeatPosition = "aisle"
+	SeatPositionMiddle SeatPosition = "middle"
+)
+
+// Index returns a SeatIndex for the seatmap.
+func (s *Seatmap) Index() *SeatIndex {
+	return NewSeatIndex(s)
+}
+
+// NewSeatIndex returns a SeatIndex for the seatmap.
+func NewSeatIndex(seatmap *Seatmap) *SeatIndex {
+	idx := &SeatIndex{
+		Seatmap:      seatmap,
+		byDesignator: make(map[string]*SeatInfo),
+	}
+
+	for c := range seatmap.Cabins {
+		cabin := &seatmap.Cabins[c]
+		for r := range cabin.Rows {
+			row := &cabin.Rows[r]
+			exitRow := rowHasElement(cabin, r, ElementTypeExitRow) ||
+				rowHasElement(cabin, r-1, ElementTypeExitRow) ||
+				rowHasElement(cabin, r+1, ElementTypeExitRow)
+			overWing := cabin.Wings.Covers(r)
+
+			for s := range row.Sections {
+				section := &row.Sections[s]
+				nearBassinet := sectionHasElement(cabin, r, s, ElementTypeBassinet) || sectionHasElement(cabin, r-1, s, ElementTypeBassinet)
+
+				first, last := seatBounds(section)
+				for e := range section.Elements {
+					element := &section.Elements[e]
+					if element.Type != ElementTypeSeat || element.Designator == "" {
+						continue
+					}
+
+					number, column := parseDesignator(element.Designator)
+					seat := &SeatInfo{
+						Element:      element,
+						Cabin:        cabin,
+						Designator:   element.Designator,
+						RowNumber:    number,
+						Column:       column,
+						Position:     seatPosition(e, first, last, s, len(row.Sections), cabin.Aisles),
+						RowIndex:     r,
+						SectionIndex: s,
+						ElementIndex: e,
+						ExitRow:      exitRow,
+						OverWing:     overWing,
+						NearBassinet: nearBassinet,
+					}
+					idx.seats = append(idx.seats, seat)
+					idx.byDesignator[strings.ToUpper(seat.Designator)] = seat
+				}
+			}
+		}
+	}
+	return idx
+}
+
+// Seat returns the seat with the given designator, e.g. "12C".
+func (idx *SeatIndex) Seat(designator string) (*SeatInfo, bool) {
+	seat, ok := idx.byDesignator[strings.ToUpper(designator)]
+	return seat, ok
+}
+
+// Seats returns every seat, from the front of the aircraft to the back.
+func (idx *SeatIndex) Seats() []*SeatInfo {
+	return idx.seats
+}
+
+// Find returns the seats that match every matcher.
+//
+//	seats := idx.Find(duffel.SeatAvailableFor(passengerID), duffel.SeatAt(duffel.SeatPositionWindow))
+func (idx *SeatIndex) Find(matchers ...SeatMatcher) []*SeatInfo {
+	seats := make([]*SeatInfo, 0)
+	for _, seat := range idx.seats {
+		if matchSeat(seat, matchers) {
+			seats = append(seats, seat)
+		}
+	}
+	return seats
+}
+
+// FindAdjacentSeats returns every group of n seats next to each other, within the
+// same section of a row so that there is no aisle between them, that are available
+// to all of the passengers. Groups are ordered from the front of the aircraft.
+// Pass no passenger IDs to find groups of seats available to anyone.
+func (idx *SeatIndex) FindAdjacentSeats(n int, passengerIDs []string) [][]*SeatInfo {
+	groups := make([][]*SeatInfo, 0)
+	if n < 1 {
+		return groups
+	}
+
+	available := func(seat *SeatInfo) bool {
+		if len(passengerIDs) == 0 {
+			return seat.Available()
+		}
+		for _, pid := range passengerIDs {
+			if !seat.AvailableFor(pid) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var run []*SeatInfo
+	for i, seat := range idx.seats {
+		if i > 0 && !sameSection(idx.seats[i-1], seat) {
+			run = nil
+		}
+		if !available(seat) {
+			run = nil
+			continue
+		}
+		if len(run) > 0 && run[len(run)-1].ElementIndex != seat.ElementIndex-1 {
+			run = nil
+		}
+
+		run = append(run, seat)
+		if len(run) >= n {
+			group := make([]*SeatInfo, n)
+			copy(group, run[len(run)-n:])
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// Available reports whether the seat can be booked by anyone.
+func (s *SeatInfo) Available() bool {
+	return len(s.Element.AvailableServices) > 0
+}
+
+// AvailableFor reports whether the seat can be booked for the passenger.
+func (s *SeatInfo) AvailableFor(passengerID string) bool {
+	_, ok := s.Service(passengerID)
+	return ok
+}
+
+// Service returns the service to book the seat for the passenger.
+func (s *SeatInfo) Service(passengerID string) (*SectionService, bool) {
+	for i := range s.Element.AvailableServices {
+		if s.Element.AvailableServices[i].PassengerID == passengerID {
+			return &s.Element.AvailableServices[i], true
+		}
+	}
+	return nil, false
+}
+
+// Price returns the price of the seat for the passenger.
+func (s *SeatInfo) Price(passengerID string) (currency.Amount, bool) {
+	service, ok := s.Service(passengerID)
+	if !ok {
+		return currency.Amount{}, false
+	}
+	return service.TotalAmount(), true
+}
+
+// Prices returns the price of the seat for each passenger it is available to.
+func (s *SeatInfo) Prices() map[string]currency.Amount {
+	prices := make(map[string]currency.Amount, len(s.Element.AvailableServices))
+	for _, service := range s.Element.AvailableServices {
+		prices[service.PassengerID] = service.TotalAmount()
+	}
+	return prices
+}
+
+// SeatAvailableFor matches seats that can be booked for the passenger.
+func SeatAvailableFor(passengerID string) SeatMatcher {
+	return func(s *SeatInfo) bool {
+		return s.AvailableFor(passengerID)
+	}
+}
+
+// SeatAt matches seats in any of the given positions.
+func SeatAt(positions ...SeatPosition) SeatMatcher {
+	return func(s *SeatInfo) bool {
+		for _, p := range positions {
+			if s.Position == p {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// SeatInCabin matches seats in the given cabin class.
+func SeatInCabin(class CabinClass) SeatMatcher {
+	return func(s *SeatInfo) bool {
+		return s.Cabin.CabinClass == class
+	}
+}
+
+// SeatInExitRow matches seats next to an exit.
+func SeatInExitRow(s *SeatInfo) bool {
+	return s.ExitRow
+}
+
+// SeatOverWing matches seats over the wings.
+func SeatOverWing(s *SeatInfo) bool {
+	return s.OverWing
+}
+
+// SeatNearBassinet matches seats near a bassinet.
+func SeatNearBassinet(s *SeatInfo) bool {
+	return s.NearBassinet
+}
+
+// SeatPricedAtMost matches seats available to the passenger for at most the given amount.
+func SeatPricedAtMost(passengerID string, max currency.Amount) SeatMatcher {
+	return func(s *SeatInfo) bool {
+		price, ok := s.Price(passengerID)
+		if !ok {
+			return false
+		}
+		cmp, err := price.Cmp(max)
+		return err == nil && cmp <= 0
+	}
+}
+
+func matchSeat(seat *SeatInfo, matchers []SeatMatcher) bool {
+	for _, m := range matchers {
+		if !m(seat) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameSection(a, b *SeatInfo) bool {
+	return a.Cabin == b.Cabin && a.RowIndex == b.RowIndex && a.SectionIndex == b.SectionIndex
+}
+
+// seatPosition works out whether a seat is by a window, an aisle or in the middle.
+// The outer edges of the first and last sections of a row are by the windows.
+// The other edges of a section are by an aisle when the cabin's aisles account
+// for every division of the row, otherwise some sections are divided by something
+// other than an aisle and those seats are in the middle. If the cabin doesn't say
+// how many aisles it has, every division is taken to be an aisle.
+func seatPosition(e, first, last, section, sections, aisles int) SeatPosition {
+	if aisles <= 0 {
+		aisles = sections - 1
+	}
+
+	switch {
+	case e == first && section == 0:
+		return SeatPositionWindow
+	case e == last && section == sections-1:
+		return SeatPositionWindow
+	case (e == first || e == last) && aisles >= sections-1:
+		return SeatPositionAisle
+	}
+	return SeatPositionMiddle
+}
+
+// seatBounds returns the indexes of the first and last seats in a section.
+func seatBounds(section *SeatSection) (first, last int) {
+	first, last = -1, -1
+	for i, e := range section.Elements {
+		if e.Type != ElementTypeSeat {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		last = i
+	}
+	return first, last
+}
+
+func rowHasElement(cabin *Cabin, r int, t ElementType) bool {
+	if r < 0 || r >= len(cabin.Rows) {
+		return false
+	}
+	for s := range cabin.Rows[r].Sections {
+		if sectionHasElement(cabin, r, s, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func sectionHasElement(cabin *Cabin, r, s int, t ElementType) bool {
+	if r < 0 || r >= len(cabin.Rows) || s >= len(cabin.Rows[r].Sections) {
+		return false
+	}
+	for _, e := range cabin.Rows[r].Sections[s].Elements {
+		if e.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDesignator splits a designator such as "12C" into its row number and column.
+func parseDesignator(designator string) (int, string) {
+	i := strings.IndexFunc(designator, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if i < 0 {
+		i = len(designator)
+	}
+	number, _ := strconv.Atoi(designator[:i])
+	return number, strings.ToUpper(designator[i:])
+}
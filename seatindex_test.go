@@ -0,0 +1,140 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bojanz/currency"
+	"github.com/segmentio/encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSeatmapPassenger = "pas_00009hj8USM7Ncg31cAAA"
+
+func loadTestSeatmap(t *testing.T) *Seatmap {
+	data, err := os.ReadFile("fixtures/200-get-seatmap.json")
+	require.NoError(t, err)
+
+	var resp struct {
+		Data []*Seatmap `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(data, &resp))
+	return resp.Data[0]
+}
+
+func TestSeatIndex(t *testing.T) {
+	a := assert.New(t)
+	idx := loadTestSeatmap(t).Index()
+
+	a.Len(idx.Seats(), 40)
+
+	seat, ok := idx.Seat("1a")
+	a.True(ok)
+	a.Equal(1, seat.RowNumber)
+	a.Equal("A", seat.Column)
+	a.Equal(SeatPositionWindow, seat.Position)
+	a.True(seat.ExitRow)
+	a.False(seat.OverWing)
+	a.True(seat.AvailableFor(testSeatmapPassenger))
+	a.False(seat.AvailableFor("pas_other"))
+
+	price, ok := seat.Price(testSeatmapPassenger)
+	a.True(ok)
+	a.Equal("30.00 GBP", price.String())
+	a.Len(seat.Prices(), 1)
+
+	seat, _ = idx.Seat("1C")
+	a.Equal(SeatPositionAisle, seat.Position)
+	seat, _ = idx.Seat("1E")
+	a.Equal(SeatPositionMiddle, seat.Position)
+	seat, _ = idx.Seat("1K")
+	a.Equal(SeatPositionWindow, seat.Position)
+	seat, _ = idx.Seat("1F")
+	a.False(seat.Available())
+
+	seat, _ = idx.Seat("2B")
+	a.True(seat.OverWing)
+	a.True(seat.ExitRow)
+	seat, _ = idx.Seat("3B")
+	a.False(seat.ExitRow)
+
+	_, ok = idx.Seat("99Z")
+	a.False(ok)
+}
+
+func TestSeatIndexCabinLayout(t *testing.T) {
+	a := assert.New(t)
+
+	// A single aisle cabin whose rows are split into three sections by a closet,
+	// with wings over the first row only.
+	var seatmap Seatmap
+	a.NoError(json.Unmarshal([]byte(`{"cabins": [{
+		"aisles": 1,
+		"wings": {"first_row_index": 0, "last_row_index": 0},
+		"rows": [
+			{"sections": [
+				{"elements": [{"type": "seat", "designator": "1A"}, {"type": "seat", "designator": "1B"}]},
+				{"elements": [{"type": "seat", "designator": "1C"}, {"type": "seat", "designator": "1D"}]},
+				{"elements": [{"type": "seat", "designator": "1E"}, {"type": "seat", "designator": "1F"}]}
+			]},
+			{"sections": [
+				{"elements": [{"type": "seat", "designator": "2A"}]}
+			]}
+		]
+	}]}`), &seatmap))
+	idx := seatmap.Index()
+
+	seat, _ := idx.Seat("1A")
+	a.Equal(SeatPositionWindow, seat.Position)
+	a.True(seat.OverWing)
+	seat, _ = idx.Seat("1B")
+	a.Equal(SeatPositionMiddle, seat.Position)
+	seat, _ = idx.Seat("2A")
+	a.False(seat.OverWing)
+
+	// Without wings no row is over them.
+	seatmap.Cabins[0].Wings = Wing{}
+	seat, _ = seatmap.Index().Seat("1A")
+	a.False(seat.OverWing)
+}
+
+func TestSeatIndexFind(t *testing.T) {
+	a := assert.New(t)
+	idx := loadTestSeatmap(t).Index()
+
+	windows := idx.Find(SeatAvailableFor(testSeatmapPassenger), SeatAt(SeatPositionWindow))
+	designators := make([]string, len(windows))
+	for i, s := range windows {
+		designators[i] = s.Designator
+	}
+	a.Equal([]string{"1A", "1K", "2A", "2K", "3A", "3K", "4A", "4K"}, designators)
+
+	a.Len(idx.Find(SeatInExitRow, SeatOverWing), 10)
+
+	max, _ := currency.NewAmount("20.00", "GBP")
+	for _, s := range idx.Find(SeatPricedAtMost(testSeatmapPassenger, max)) {
+		price, _ := s.Price(testSeatmapPassenger)
+		cmp, _ := price.Cmp(max)
+		a.LessOrEqual(cmp, 0)
+	}
+}
+
+func TestSeatIndexFindAdjacentSeats(t *testing.T) {
+	a := assert.New(t)
+	idx := loadTestSeatmap(t).Index()
+
+	groups := idx.FindAdjacentSeats(3, []string{testSeatmapPassenger})
+	var got []string
+	for _, g := range groups {
+		got = append(got, g[0].Designator+"-"+g[2].Designator)
+	}
+	a.Equal([]string{"1A-1C", "1H-1K", "2A-2C", "3D-3F", "3H-3K", "4A-4C", "4D-4F", "4E-4G"}, got)
+
+	a.Empty(idx.FindAdjacentSeats(5, nil))
+	a.Empty(idx.FindAdjacentSeats(2, []string{"pas_other"}))
+}
@@ -0,0 +1,437 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/segmentio/encoding/json"
+)
+
+type (
+	// SeatmapRenderOption configures how a seatmap is rendered.
+	SeatmapRenderOption func(*seatmapRenderOptions)
+
+	seatmapRenderOptions struct {
+		passengerID string
+		selected    map[string]bool
+		decks       map[int]bool
+		ansi        bool
+	}
+
+	// SeatGrid is a seatmap laid out as a grid of rows and columns,
+	// with aisles as columns of their own, ready to be drawn by a frontend.
+	SeatGrid struct {
+		SeatmapID string      `json:"seatmap_id"`
+		SegmentID string      `json:"segment_id"`
+		SliceID   string      `json:"slice_id"`
+		Cabins    []GridCabin `json:"cabins"`
+	}
+
+	// GridCabin is a cabin of a SeatGrid.
+	GridCabin struct {
+		Deck       int        `json:"deck"`
+		CabinClass CabinClass `json:"cabin_class"`
+		// Columns are the column letters of the grid, with an empty string for aisles.
+		Columns []string  `json:"columns"`
+		Rows    []GridRow `json:"rows"`
+	}
+
+	// GridRow is a row of a GridCabin. Every row has a cell for each column.
+	GridRow struct {
+		// Number is the seat row number, or 0 for rows without seats such as exits.
+		Number   int        `json:"number"`
+		OverWing bool       `json:"over_wing"`
+		ExitRow  bool       `json:"exit_row"`
+		Cells    []GridCell `json:"cells"`
+	}
+
+	// GridCell is a single cell of a GridRow.
+	GridCell struct {
+		// Type is the element type, or "aisle" for aisles.
+		Type       string       `json:"type"`
+		Designator string       `json:"designator,omitempty"`
+		Position   SeatPosition `json:"position,omitempty"`
+		Available  bool         `json:"available,omitempty"`
+		Selected   bool         `json:"selected,omitempty"`
+		// Price is the price of the seat for the passenger given with WithRenderPassenger, e.g. "30.00 GBP".
+		Price string `json:"price,omitempty"`
+		// Paid is true if the seat has a price other than zero for the passenger.
+		Paid bool `json:"paid,omitempty"`
+	}
+)
+
+// gridCellAisle is the type of the cells between sections.
+const gridCellAisle = "aisle"
+
+// WithRenderPassenger shows availability and prices for the passenger rather than for anyone.
+func WithRenderPassenger(passengerID string) SeatmapRenderOption {
+	return func(o *seatmapRenderOptions) {
+		o.passengerID = passengerID
+	}
+}
+
+// WithSelectedSeats highlights the seats with the given designators.
+func WithSelectedSeats(designators ...string) SeatmapRenderOption {
+	return func(o *seatmapRenderOptions) {
+		for _, d := range designators {
+			o.selected[strings.ToUpper(d)] = true
+		}
+	}
+}
+
+// WithDecks only renders the cabins on the given decks, where 0 is the main deck.
+func WithDecks(decks ...int) SeatmapRenderOption {
+	return func(o *seatmapRenderOptions) {
+		o.decks = make(map[int]bool, len(decks))
+		for _, d := range decks {
+			o.decks[d] = true
+		}
+	}
+}
+
+// WithANSIColors colours the text rendering for terminals, whatever the writer.
+// Colours are left out if NO_COLOR is set.
+func WithANSIColors() SeatmapRenderOption {
+	return func(o *seatmapRenderOptions) {
+		_, noColor := os.LookupEnv("NO_COLOR")
+		o.ansi = !noColor
+	}
+}
+
+// Grid lays the seatmap out as a grid of rows and columns.
+func (s *Seatmap) Grid(opts ...SeatmapRenderOption) *SeatGrid {
+	o := newSeatmapRenderOptions(opts)
+	idx := s.Index()
+
+	grid := &SeatGrid{
+		SeatmapID: s.ID,
+		SegmentID: s.SegmentID,
+		SliceID:   s.SliceID,
+		Cabins:    make([]GridCabin, 0, len(s.Cabins)),
+	}
+	for c := range s.Cabins {
+		cabin := &s.Cabins[c]
+		if o.decks != nil && !o.decks[cabin.Deck] {
+			continue
+		}
+		grid.Cabins = append(grid.Cabins, newGridCabin(cabin, idx, o))
+	}
+	return grid
+}
+
+// RenderJSON writes the seatmap's grid as JSON.
+func (s *Seatmap) RenderJSON(w io.Writer, opts ...SeatmapRenderOption) error {
+	return json.NewEncoder(w).Encode(s.Grid(opts...))
+}
+
+// RenderText draws the seatmap as a grid of characters for terminals:
+//
+//	.  available     x  unavailable   *  selected
+//	$  paid
+//	E  exit          L  lavatory      G  galley
+//	C  closet        S  stairs        B  bassinet
+//
+// Paid seats are marked for the passenger given with WithRenderPassenger.
+// Rows over the wings are marked with = either side.
+func (s *Seatmap) RenderText(w io.Writer, opts ...SeatmapRenderOption) error {
+	o := newSeatmapRenderOptions(opts)
+	grid := s.Grid(opts...)
+	bw := bufio.NewWriter(w)
+
+	for i, cabin := range grid.Cabins {
+		if i > 0 {
+			fmt.Fprintln(bw)
+		}
+		fmt.Fprintln(bw, cabinTitle(cabin, grid.multiDeck()))
+
+		fmt.Fprint(bw, "     ")
+		for _, col := range cabin.Columns {
+			if col == "" {
+				col = " "
+			}
+			fmt.Fprintf(bw, "%s ", col)
+		}
+		fmt.Fprintln(bw)
+
+		for _, row := range cabin.Rows {
+			wing := " "
+			if row.OverWing {
+				wing = "="
+			}
+			if row.Number > 0 {
+				fmt.Fprintf(bw, "%3d %s", row.Number, wing)
+			} else {
+				fmt.Fprintf(bw, "    %s", wing)
+			}
+			for _, cell := range row.Cells {
+				fmt.Fprintf(bw, "%s ", textCell(cell, o.ansi))
+			}
+			fmt.Fprintf(bw, "%s\n", wing)
+		}
+	}
+	return bw.Flush()
+}
+
+const (
+	svgCell   = 32
+	svgMargin = 48
+)
+
+// RenderSVG draws the seatmap as an SVG diagram, with the wings either side of
+// the rows over them and labelled exits, lavatories, galleys, closets, stairs
+// and bassinets. Cabins are drawn one after another, front to back.
+func (s *Seatmap) RenderSVG(w io.Writer, opts ...SeatmapRenderOption) error {
+	grid := s.Grid(opts...)
+
+	columns, rows := 0, 0
+	for _, cabin := range grid.Cabins {
+		if len(cabin.Columns) > columns {
+			columns = len(cabin.Columns)
+		}
+		rows += len(cabin.Rows) + 1
+	}
+	width := columns*svgCell + 2*svgMargin
+	height := rows*svgCell + svgMargin
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="10">`+"\n", width, height, width, height)
+
+	y := svgMargin / 2
+	for _, cabin := range grid.Cabins {
+		fmt.Fprintf(bw, `<text x="%d" y="%d" font-size="12" font-weight="bold">%s</text>`+"\n", svgMargin, y+svgCell/2, html.EscapeString(cabinTitle(cabin, grid.multiDeck())))
+		y += svgCell
+
+		left := svgMargin + (columns-len(cabin.Columns))*svgCell/2
+		right := left + len(cabin.Columns)*svgCell
+		for r, row := range cabin.Rows {
+			top := y + r*svgCell
+			if row.OverWing {
+				fmt.Fprintf(bw, `<rect class="wing" x="%d" y="%d" width="%d" height="%d" fill="#d0d7e1"/>`+"\n", left-svgMargin+8, top, svgMargin-12, svgCell)
+				fmt.Fprintf(bw, `<rect class="wing" x="%d" y="%d" width="%d" height="%d" fill="#d0d7e1"/>`+"\n", right+4, top, svgMargin-12, svgCell)
+			}
+			if row.Number > 0 {
+				fmt.Fprintf(bw, `<text x="%d" y="%d" text-anchor="end" fill="#666">%d</text>`+"\n", left-4, top+svgCell/2+4, row.Number)
+			}
+			for c, cell := range row.Cells {
+				writeSVGCell(bw, cell, left+c*svgCell, top)
+			}
+		}
+		y += len(cabin.Rows) * svgCell
+	}
+
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+func newSeatmapRenderOptions(opts []SeatmapRenderOption) *seatmapRenderOptions {
+	o := &seatmapRenderOptions{
+		selected: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func newGridCabin(cabin *Cabin, idx *SeatIndex, o *seatmapRenderOptions) GridCabin {
+	// Each section is as wide as its widest row, with an aisle between sections.
+	var widths []int
+	for _, row := range cabin.Rows {
+		for s, section := range row.Sections {
+			if s >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if len(section.Elements) > widths[s] {
+				widths[s] = len(section.Elements)
+			}
+		}
+	}
+	offsets := make([]int, len(widths))
+	total := 0
+	for s, width := range widths {
+		if s > 0 {
+			total++
+		}
+		offsets[s] = total
+		total += width
+	}
+
+	gc := GridCabin{
+		Deck:       cabin.Deck,
+		CabinClass: cabin.CabinClass,
+		Columns:    make([]string, total),
+		Rows:       make([]GridRow, 0, len(cabin.Rows)),
+	}
+
+	for r := range cabin.Rows {
+		row := &cabin.Rows[r]
+		gr := GridRow{
+			OverWing: cabin.Wings.Covers(r),
+			Cells:    make([]GridCell, total),
+		}
+		for c := range gr.Cells {
+			gr.Cells[c] = GridCell{Type: string(ElementTypeEmpty)}
+		}
+		for s := 1; s < len(offsets); s++ {
+			gr.Cells[offsets[s]-1] = GridCell{Type: gridCellAisle}
+		}
+
+		for s := range row.Sections {
+			section := &row.Sections[s]
+			for e := range section.Elements {
+				element := &section.Elements[e]
+				col := offsets[s] + e
+				cell := GridCell{Type: string(element.Type)}
+				if element.Type == ElementTypeExitRow {
+					gr.ExitRow = true
+				}
+
+				if seat, ok := idx.Seat(element.Designator); ok && seat.Element == element {
+					gr.Number = seat.RowNumber
+					gc.Columns[col] = seat.Column
+					cell.Designator = seat.Designator
+					cell.Position = seat.Position
+					cell.Selected = o.selected[strings.ToUpper(seat.Designator)]
+					if o.passengerID != "" {
+						if price, ok := seat.Price(o.passengerID); ok {
+							cell.Available = true
+							cell.Price = price.String()
+							cell.Paid = !price.IsZero()
+						}
+					} else {
+						cell.Available = seat.Available()
+					}
+				}
+				gr.Cells[col] = cell
+			}
+		}
+		gc.Rows = append(gc.Rows, gr)
+	}
+	return gc
+}
+
+// cabinTitle names a cabin, along with its deck if the seatmap has more than one.
+func cabinTitle(cabin GridCabin, multiDeck bool) string {
+	if multiDeck {
+		return fmt.Sprintf("Deck %d, %s", cabin.Deck, cabin.CabinClass)
+	}
+	return string(cabin.CabinClass)
+}
+
+func (g *SeatGrid) multiDeck() bool {
+	for _, cabin := range g.Cabins {
+		if cabin.Deck != g.Cabins[0].Deck {
+			return true
+		}
+	}
+	return false
+}
+
+var textSymbols = map[string]string{
+	string(ElementTypeBassinet): "B",
+	string(ElementTypeEmpty):    " ",
+	string(ElementTypeExitRow):  "E",
+	string(ElementTypeLavatory): "L",
+	string(ElementTypeGalley):   "G",
+	string(ElementTypeCloset):   "C",
+	string(ElementTypeStairs):   "S",
+	gridCellAisle:               " ",
+}
+
+var (
+	exitColor        = newTextColor(color.FgRed)
+	selectedColor    = newTextColor(color.Bold, color.FgYellow)
+	availableColor   = newTextColor(color.FgGreen)
+	paidColor        = newTextColor(color.FgCyan)
+	unavailableColor = newTextColor(color.FgHiBlack)
+)
+
+// newTextColor returns a colour that is always enabled, since the text may be
+// written somewhere other than standard output. WithANSIColors decides whether
+// it is used.
+func newTextColor(attrs ...color.Attribute) *color.Color {
+	c := color.New(attrs...)
+	c.EnableColor()
+	return c
+}
+
+func textCell(cell GridCell, ansi bool) string {
+	var (
+		symbol string
+		c      *color.Color
+	)
+
+	switch {
+	case cell.Type != string(ElementTypeSeat):
+		var ok bool
+		if symbol, ok = textSymbols[cell.Type]; !ok {
+			symbol = "?"
+		}
+		if cell.Type == string(ElementTypeExitRow) {
+			c = exitColor
+		}
+	case cell.Selected:
+		symbol, c = "*", selectedColor
+	case cell.Available && cell.Paid:
+		symbol, c = "$", paidColor
+	case cell.Available:
+		symbol, c = ".", availableColor
+	default:
+		symbol, c = "x", unavailableColor
+	}
+
+	if ansi && c != nil {
+		return c.Sprint(symbol)
+	}
+	return symbol
+}
+
+var svgLabels = map[string]string{
+	string(ElementTypeBassinet): "B",
+	string(ElementTypeExitRow):  "EXIT",
+	string(ElementTypeLavatory): "WC",
+	string(ElementTypeGalley):   "G",
+	string(ElementTypeCloset):   "C",
+	string(ElementTypeStairs):   "S",
+}
+
+func writeSVGCell(w io.Writer, cell GridCell, x, y int) {
+	switch cell.Type {
+	case string(ElementTypeEmpty), gridCellAisle:
+		return
+	case string(ElementTypeSeat):
+		fill := "#cccccc"
+		switch {
+		case cell.Selected:
+			fill = "#f5a623"
+		case cell.Available && cell.Paid:
+			fill = "#7fb2e5"
+		case cell.Available:
+			fill = "#8fd694"
+		}
+		title := cell.Designator
+		if cell.Price != "" {
+			title += " " + cell.Price
+		}
+		fmt.Fprintf(w, `<g class="seat"><title>%s</title><rect x="%d" y="%d" width="%d" height="%d" rx="5" fill="%s" stroke="#555"/>`, html.EscapeString(title), x+2, y+2, svgCell-4, svgCell-4, fill)
+		fmt.Fprintf(w, `<text x="%d" y="%d" text-anchor="middle">%s</text></g>`+"\n", x+svgCell/2, y+svgCell/2+4, html.EscapeString(cell.Designator))
+	case string(ElementTypeExitRow):
+		fmt.Fprintf(w, `<text class="exit" x="%d" y="%d" text-anchor="middle" fill="#d0021b" font-weight="bold">%s</text>`+"\n", x+svgCell/2, y+svgCell/2+4, svgLabels[cell.Type])
+	default:
+		label, ok := svgLabels[cell.Type]
+		if !ok {
+			label = cell.Type
+		}
+		fmt.Fprintf(w, `<g class="%s"><rect x="%d" y="%d" width="%d" height="%d" fill="#eeeeee" stroke="#999"/>`, html.EscapeString(cell.Type), x+2, y+2, svgCell-4, svgCell-4)
+		fmt.Fprintf(w, `<text x="%d" y="%d" text-anchor="middle" fill="#555">%s</text></g>`+"\n", x+svgCell/2, y+svgCell/2+4, html.EscapeString(label))
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeatmapGrid(t *testing.T) {
+	a := assert.New(t)
+	seatmap := loadTestSeatmap(t)
+
+	grid := seatmap.Grid(WithRenderPassenger(testSeatmapPassenger), WithSelectedSeats("1a"))
+	require.Len(t, grid.Cabins, 1)
+
+	cabin := grid.Cabins[0]
+	a.Equal([]string{"A", "B", "C", "", "D", "E", "F", "G", "", "H", "J", "K"}, cabin.Columns)
+	a.Len(cabin.Rows, 7)
+	for _, row := range cabin.Rows {
+		a.Len(row.Cells, len(cabin.Columns))
+		a.Equal(gridCellAisle, row.Cells[3].Type)
+		a.Equal(gridCellAisle, row.Cells[8].Type)
+	}
+
+	first := cabin.Rows[0]
+	a.Equal(1, first.Number)
+	a.Equal(GridCell{
+		Type:       string(ElementTypeSeat),
+		Designator: "1A",
+		Position:   SeatPositionWindow,
+		Available:  true,
+		Selected:   true,
+		Price:      "30.00 GBP",
+		Paid:       true,
+	}, first.Cells[0])
+	a.False(first.Cells[6].Available)
+
+	exit := cabin.Rows[1]
+	a.Equal(0, exit.Number)
+	a.True(exit.ExitRow)
+	a.True(exit.OverWing)
+	a.Equal(string(ElementTypeExitRow), exit.Cells[0].Type)
+
+	a.Equal(string(ElementTypeLavatory), cabin.Rows[5].Cells[0].Type)
+	a.Equal(string(ElementTypeGalley), cabin.Rows[6].Cells[0].Type)
+
+	var b bytes.Buffer
+	require.NoError(t, seatmap.RenderJSON(&b))
+	var decoded SeatGrid
+	require.NoError(t, json.Unmarshal(b.Bytes(), &decoded))
+	a.Equal(seatmap.ID, decoded.SeatmapID)
+	a.Equal(cabin.Columns, decoded.Cabins[0].Columns)
+}
+
+func TestSeatmapGridDecks(t *testing.T) {
+	a := assert.New(t)
+	seatmap := loadTestSeatmap(t)
+
+	upper := seatmap.Cabins[0]
+	upper.Deck = 1
+	upper.CabinClass = CabinClassBusiness
+	seatmap.Cabins = append(seatmap.Cabins, upper)
+
+	a.Len(seatmap.Grid().Cabins, 2)
+	a.True(seatmap.Grid().multiDeck())
+
+	grid := seatmap.Grid(WithDecks(1))
+	require.Len(t, grid.Cabins, 1)
+	a.Equal(1, grid.Cabins[0].Deck)
+
+	var b bytes.Buffer
+	require.NoError(t, seatmap.RenderText(&b))
+	a.Contains(b.String(), "Deck 0, economy")
+	a.Contains(b.String(), "Deck 1, business")
+}
+
+func TestSeatmapRenderText(t *testing.T) {
+	a := assert.New(t)
+	seatmap := loadTestSeatmap(t)
+
+	var b bytes.Buffer
+	require.NoError(t, seatmap.RenderText(&b, WithSelectedSeats("1A")))
+	lines := strings.Split(b.String(), "\n")
+	a.Equal("economy", lines[0])
+	a.Equal("     A B C   D E F G   H J K ", lines[1])
+	a.Equal("  1  * . .   . . x x   . . .  ", lines[2])
+	a.Equal("    =E                 E     =", lines[3])
+	a.Equal("     L                 L      ", lines[7])
+
+	// Paid seats are marked for the passenger.
+	b.Reset()
+	require.NoError(t, seatmap.RenderText(&b, WithRenderPassenger(testSeatmapPassenger)))
+	a.Equal("  1  $ $ $   $ $ x x   $ $ $  ", strings.Split(b.String(), "\n")[2])
+
+	// Colours are written to any writer once asked for.
+	b.Reset()
+	require.NoError(t, seatmap.RenderText(&b, WithANSIColors()))
+	a.Contains(b.String(), "\x1b[32m.\x1b[0m")
+	a.Contains(b.String(), "\x1b[90mx\x1b[0m")
+
+	t.Setenv("NO_COLOR", "1")
+	b.Reset()
+	require.NoError(t, seatmap.RenderText(&b, WithANSIColors()))
+	a.NotContains(b.String(), "\x1b[")
+}
+
+func TestSeatmapRenderSVG(t *testing.T) {
+	a := assert.New(t)
+	seatmap := loadTestSeatmap(t)
+
+	var b bytes.Buffer
+	require.NoError(t, seatmap.RenderSVG(&b, WithRenderPassenger(testSeatmapPassenger), WithSelectedSeats("2A")))
+	svg := b.String()
+
+	a.True(strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg"`))
+	a.True(strings.HasSuffix(svg, "</svg>\n"))
+	a.Equal(4, strings.Count(svg, `class="wing"`))
+	a.Equal(2, strings.Count(svg, `class="exit"`))
+	a.Equal(2, strings.Count(svg, `class="lavatory"`))
+	a.Equal(3, strings.Count(svg, `class="galley"`))
+	a.Contains(svg, `<title>1A 30.00 GBP</title><rect x="50" y="58" width="28" height="28" rx="5" fill="#7fb2e5"`)
+	a.Contains(svg, `<title>2A 20.00 GBP</title><rect x="50" y="122" width="28" height="28" rx="5" fill="#f5a623"`)
+}
@@ -8,6 +8,7 @@ import (
 	"context"
 
 	"github.com/bojanz/currency"
+	"github.com/segmentio/encoding/json"
 )
 
 type (
@@ -77,6 +78,10 @@ type (
 		FirstRowIndex int `json:"first_row_index"`
 		// The index of the last row which is overwing, starting from the front of the aircraft.
 		LastRowIndex int `json:"last_row_index"`
+
+		// present is set when the wings were in the payload, so that wings covering
+		// only the first row can be told apart from no wings at all.
+		present bool
 	}
 
 	SeatmapClient interface {
@@ -101,6 +106,28 @@ func (e ElementType) String() string {
 	return string(e)
 }
 
+// UnmarshalJSON implements the json.Unmarshaler, recording that the wings were present.
+func (w *Wing) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+
+	type wing Wing
+	var v wing
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*w = Wing(v)
+	w.present = true
+	return nil
+}
+
+// Covers returns true if the row at the given index is over the wings.
+// A zero-value Wing means the cabin has no wings.
+func (w Wing) Covers(rowIndex int) bool {
+	return w != (Wing{}) && rowIndex >= w.FirstRowIndex && rowIndex <= w.LastRowIndex
+}
+
 func (a *API) SeatmapForOffer(ctx context.Context, offer Offer) ([]*Seatmap, error) {
 	return a.GetSeatmap(ctx, offer.ID)
 }
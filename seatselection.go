@@ -0,0 +1,191 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bojanz/currency"
+)
+
+type (
+	// SeatSelection collects the seats chosen by each passenger on each segment
+	// and turns them into the services and payment amount of an order.
+	SeatSelection struct {
+		seatmaps   map[string]*SeatIndex
+		selections []*SelectedSeat
+	}
+
+	// SelectedSeat is a seat chosen for a passenger on a segment.
+	SelectedSeat struct {
+		SegmentID   string
+		PassengerID string
+		Seat        *SeatInfo
+		Service     *SectionService
+	}
+)
+
+// NewSeatSelection returns an empty selection for the seatmaps of an offer.
+func NewSeatSelection(seatmaps []*Seatmap) *SeatSelection {
+	s := &SeatSelection{
+		seatmaps: make(map[string]*SeatIndex, len(seatmaps)),
+	}
+	for _, seatmap := range seatmaps {
+		s.seatmaps[seatmap.SegmentID] = seatmap.Index()
+	}
+	return s
+}
+
+// Select chooses the seat with the given designator for the passenger on the segment.
+// It returns an error if the seat doesn't exist, isn't available to the passenger,
+// has already been chosen for another passenger or the passenger already has a seat
+// on the segment. Use Remove to change a passenger's seat.
+func (s *SeatSelection) Select(segmentID, passengerID, designator string) error {
+	idx, ok := s.seatmaps[segmentID]
+	if !ok {
+		return seatSelectionError(InvalidDataParam, fmt.Sprintf("there is no seatmap for segment %s", segmentID))
+	}
+	seat, ok := idx.Seat(designator)
+	if !ok {
+		return seatSelectionError(InvalidDataParam, fmt.Sprintf("segment %s has no seat %s", segmentID, designator))
+	}
+	service, ok := seat.Service(passengerID)
+	if !ok {
+		return seatSelectionError(AncillaryServiceNotAvailable, fmt.Sprintf("seat %s is not available for passenger %s", seat.Designator, passengerID))
+	}
+
+	for _, selected := range s.selections {
+		if selected.SegmentID != segmentID {
+			continue
+		}
+		if selected.PassengerID == passengerID {
+			return seatSelectionError(InvalidDataParam, fmt.Sprintf("passenger %s already has seat %s on segment %s", passengerID, selected.Seat.Designator, segmentID))
+		}
+		if selected.Seat == seat {
+			return seatSelectionError(AncillaryServiceNotAvailable, fmt.Sprintf("seat %s is already selected for passenger %s", seat.Designator, selected.PassengerID))
+		}
+	}
+
+	s.selections = append(s.selections, &SelectedSeat{
+		SegmentID:   segmentID,
+		PassengerID: passengerID,
+		Seat:        seat,
+		Service:     service,
+	})
+	return nil
+}
+
+// Remove removes the passenger's seat on the segment, if one was selected.
+func (s *SeatSelection) Remove(segmentID, passengerID string) {
+	for i, selected := range s.selections {
+		if selected.SegmentID == segmentID && selected.PassengerID == passengerID {
+			s.selections = append(s.selections[:i], s.selections[i+1:]...)
+			return
+		}
+	}
+}
+
+// Selected returns the selected seats in the order they were chosen.
+func (s *SeatSelection) Selected() []*SelectedSeat {
+	return s.selections
+}
+
+// Services returns the services to book the selected seats.
+func (s *SeatSelection) Services() []ServiceCreateInput {
+	services := make([]ServiceCreateInput, len(s.selections))
+	for i, selected := range s.selections {
+		services[i] = ServiceCreateInput{ID: selected.Service.ID, Quantity: 1}
+	}
+	return services
+}
+
+// TotalAmount returns the total price of the selected seats, to be added to the
+// payment for the order. It returns a zero amount in currencyCode if no seats are selected.
+func (s *SeatSelection) TotalAmount(currencyCode string) (currency.Amount, error) {
+	total, err := currency.NewAmount("0", currencyCode)
+	if err != nil {
+		return currency.Amount{}, err
+	}
+	for _, selected := range s.selections {
+		total, err = total.Add(selected.Service.TotalAmount())
+		if err != nil {
+			return currency.Amount{}, err
+		}
+	}
+	return total, nil
+}
+
+// ApplyTo adds the selected seats to the order's services, and their price to
+// the order's first payment if it has one. Seat services already in the order,
+// e.g. from an earlier call, are replaced, so it can be called again after the
+// selection changes.
+func (s *SeatSelection) ApplyTo(input *CreateOrderInput) error {
+	services := make([]ServiceCreateInput, 0, len(input.Services)+len(s.selections))
+	var earlier []ServiceCreateInput
+	for _, service := range input.Services {
+		if _, ok := s.seatService(service.ID); ok {
+			earlier = append(earlier, service)
+			continue
+		}
+		services = append(services, service)
+	}
+	if len(s.selections) == 0 && len(earlier) == 0 {
+		return nil
+	}
+
+	if len(input.Payments) > 0 {
+		payment := &input.Payments[0]
+		amount, err := currency.NewAmount(payment.Amount, payment.Currency)
+		if err != nil {
+			return err
+		}
+		for _, service := range earlier {
+			seat, _ := s.seatService(service.ID)
+			price, err := seat.TotalAmount().Mul(strconv.Itoa(service.Quantity))
+			if err != nil {
+				return err
+			}
+			if amount, err = amount.Sub(price); err != nil {
+				return err
+			}
+		}
+		seats, err := s.TotalAmount(payment.Currency)
+		if err != nil {
+			return err
+		}
+		amount, err = amount.Add(seats)
+		if err != nil {
+			return err
+		}
+		payment.Amount = amount.Number()
+	}
+
+	input.Services = append(services, s.Services()...)
+	return nil
+}
+
+// seatService returns the seat service with the given ID from any of the seatmaps.
+func (s *SeatSelection) seatService(id string) (*SectionService, bool) {
+	for _, idx := range s.seatmaps {
+		for _, seat := range idx.Seats() {
+			for i := range seat.Element.AvailableServices {
+				if service := &seat.Element.AvailableServices[i]; service.ID == id {
+					return service, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+func seatSelectionError(code ErrorCode, message string) error {
+	return &DuffelError{Errors: []Error{{
+		Type:    ValidationError,
+		Title:   "Invalid seat selection",
+		Message: message,
+		Code:    code,
+	}}}
+}
@@ -0,0 +1,79 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeatSelection(t *testing.T) {
+	a := assert.New(t)
+
+	seatmap := loadTestSeatmap(t)
+	// Make 1A available to a second passenger too.
+	seatmap.Cabins[0].Rows[0].Sections[0].Elements[0].AvailableServices = append(
+		seatmap.Cabins[0].Rows[0].Sections[0].Elements[0].AvailableServices,
+		SectionService{ID: "ase_second_1A", PassengerID: "pas_second", RawTotalAmount: "30.00", RawTotalCurrency: "GBP"},
+	)
+
+	segmentID := "seg_00009htYpSCXrwaB9Dn456"
+	selection := NewSeatSelection([]*Seatmap{seatmap})
+
+	a.NoError(selection.Select(segmentID, testSeatmapPassenger, "1A"))
+
+	err := selection.Select(segmentID, testSeatmapPassenger, "2A")
+	a.True(IsErrorCode(err, InvalidDataParam))
+
+	err = selection.Select(segmentID, "pas_second", "1A")
+	a.True(IsErrorCode(err, AncillaryServiceNotAvailable))
+	a.EqualError(err, "duffel: seat 1A is already selected for passenger pas_00009hj8USM7Ncg31cAAA")
+
+	err = selection.Select(segmentID, "pas_second", "2A")
+	a.True(IsErrorCode(err, AncillaryServiceNotAvailable))
+
+	a.True(IsErrorCode(selection.Select(segmentID, testSeatmapPassenger, "99Z"), InvalidDataParam))
+	a.True(IsErrorCode(selection.Select("seg_unknown", testSeatmapPassenger, "1A"), InvalidDataParam))
+
+	// Change seats.
+	selection.Remove(segmentID, testSeatmapPassenger)
+	a.NoError(selection.Select(segmentID, testSeatmapPassenger, "2A"))
+	a.NoError(selection.Select(segmentID, "pas_second", "1A"))
+
+	a.Equal([]ServiceCreateInput{
+		{ID: "ase_00009UhD4ongolulWAAA2A", Quantity: 1},
+		{ID: "ase_second_1A", Quantity: 1},
+	}, selection.Services())
+
+	total, err := selection.TotalAmount("GBP")
+	a.NoError(err)
+	a.Equal("50.00 GBP", total.String())
+
+	input := CreateOrderInput{
+		SelectedOffers: []string{"off_00009htYpSCXrwaB9DnUm0"},
+		Services:       []ServiceCreateInput{{ID: "ase_bag", Quantity: 1}},
+		Payments:       []PaymentCreateInput{{Amount: "100.00", Currency: "GBP", Type: PaymentMethodBalance}},
+	}
+	a.NoError(selection.ApplyTo(&input))
+	a.Len(input.Services, 3)
+	a.Equal("150.00", input.Payments[0].Amount)
+
+	// Applying again replaces the seats applied before.
+	a.NoError(selection.ApplyTo(&input))
+	a.Len(input.Services, 3)
+	a.Equal("150.00", input.Payments[0].Amount)
+
+	selection.Remove(segmentID, "pas_second")
+	a.NoError(selection.ApplyTo(&input))
+	a.Equal([]ServiceCreateInput{
+		{ID: "ase_bag", Quantity: 1},
+		{ID: "ase_00009UhD4ongolulWAAA2A", Quantity: 1},
+	}, input.Services)
+	a.Equal("120.00", input.Payments[0].Amount)
+
+	input.Payments[0].Currency = "USD"
+	a.Error(selection.ApplyTo(&input))
+}
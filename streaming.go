@@ -0,0 +1,191 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/segmentio/encoding/json"
+)
+
+// maxPooledBufferSize stops unusually large responses from being kept in the pool.
+const maxPooledBufferSize = 16 << 20
+
+var responseBufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// streamDecoder decodes the items of a list response one at a time, so that
+// only the raw response and the current item are held in memory rather than
+// every decoded item on the page.
+//
+// The response body is read into a pooled buffer, which is returned to the
+// pool once every item has been decoded or the decoder is released.
+type streamDecoder[T any] struct {
+	buf    *bytes.Buffer
+	body   []byte
+	tok    *json.Tokenizer
+	meta   *ListMeta
+	inData bool
+	err    error
+}
+
+func newStreamDecoder[T any](r io.Reader) (*streamDecoder[T], error) {
+	buf := responseBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+
+	if _, err := buf.ReadFrom(r); err != nil {
+		responseBufferPool.Put(buf)
+		return nil, err
+	}
+
+	d := &streamDecoder[T]{
+		buf:  buf,
+		body: buf.Bytes(),
+	}
+	d.tok = json.NewTokenizer(d.body)
+
+	if !d.tok.Next() || d.tok.Delim != '{' {
+		d.fail(fmt.Errorf("expected a JSON object"))
+		return nil, d.err
+	}
+
+	d.readObject()
+	if d.err != nil {
+		return nil, d.err
+	}
+	return d, nil
+}
+
+// next decodes the next item of the data array.
+// It returns false once the array is exhausted or decoding failed.
+func (d *streamDecoder[T]) next() (*T, bool) {
+	if d.err != nil || !d.inData {
+		return nil, false
+	}
+
+	if !d.tok.Next() {
+		d.fail(d.tok.Err)
+		return nil, false
+	}
+
+	if d.tok.Delim == ',' && !d.tok.Next() {
+		d.fail(d.tok.Err)
+		return nil, false
+	}
+
+	if d.tok.Delim == ']' {
+		d.inData = false
+		d.readObject()
+		return nil, false
+	}
+
+	raw, ok := d.readValue()
+	if !ok {
+		return nil, false
+	}
+
+	item := new(T)
+	if err := json.Unmarshal(raw, item); err != nil {
+		d.fail(err)
+		return nil, false
+	}
+	return item, true
+}
+
+// readObject reads the keys of the response object until it reaches the data array,
+// which is then decoded by next, or the end of the response.
+func (d *streamDecoder[T]) readObject() {
+	for d.tok.Next() {
+		if d.tok.Delim == '}' && d.tok.Depth == 0 {
+			d.release()
+			return
+		}
+		if !d.tok.IsKey {
+			continue
+		}
+
+		key := string(d.tok.Value.Unquote())
+		if !d.tok.Next() || d.tok.Delim != ':' || !d.tok.Next() {
+			d.fail(d.tok.Err)
+			return
+		}
+
+		switch {
+		case key == "data" && d.tok.Delim == '[':
+			d.inData = true
+			return
+		case key == "meta":
+			raw, ok := d.readValue()
+			if !ok {
+				return
+			}
+			meta := new(ListMeta)
+			if err := json.Unmarshal(raw, meta); err != nil {
+				d.fail(err)
+				return
+			}
+			d.meta = meta
+		default:
+			if _, ok := d.readValue(); !ok {
+				return
+			}
+		}
+	}
+
+	d.fail(d.tok.Err)
+}
+
+// readValue returns the raw JSON of the value starting at the current token.
+func (d *streamDecoder[T]) readValue() ([]byte, bool) {
+	start := d.offset(d.tok.Value)
+
+	if d.tok.Delim == '{' || d.tok.Delim == '[' {
+		depth := d.tok.Depth
+		for d.tok.Next() {
+			if (d.tok.Delim == '}' || d.tok.Delim == ']') && d.tok.Depth == depth {
+				return d.body[start : d.offset(d.tok.Value)+1], true
+			}
+		}
+		d.fail(d.tok.Err)
+		return nil, false
+	}
+
+	return d.body[start : start+len(d.tok.Value)], true
+}
+
+// offset returns the position of a token within the body. Tokens are always
+// sub-slices of the body, so their position can be derived from their capacity.
+func (d *streamDecoder[T]) offset(v []byte) int {
+	return cap(d.body) - cap(v)
+}
+
+func (d *streamDecoder[T]) fail(err error) {
+	if err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+	d.err = err
+	d.inData = false
+	d.release()
+}
+
+// release returns the response buffer to the pool. The decoder must not be used afterwards.
+func (d *streamDecoder[T]) release() {
+	if d.buf == nil {
+		return
+	}
+
+	if d.buf.Cap() <= maxPooledBufferSize {
+		responseBufferPool.Put(d.buf)
+	}
+	d.buf = nil
+	d.body = nil
+	d.tok = nil
+}
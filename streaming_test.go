@@ -0,0 +1,77 @@
+// Copyright 2021-present Airheart, Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package duffel
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestStreamingDecodeListOrders(t *testing.T) {
+	defer gock.Off()
+	a := assert.New(t)
+	mockListOrdersPages()
+
+	ctx := context.TODO()
+	client := New("duffel_test_123", WithStreamingDecode())
+	iter := client.ListOrders(ctx)
+
+	// meta precedes data in the response, so it is known before any item is decoded.
+	a.Equal(&ListMeta{After: "g2wAAAACbQAAABBBZXJvbWlzdC1LaGFya2l2bQAAAB=", Limit: 50}, iter.Meta())
+	a.Empty(iter.List().GetItems())
+
+	a.True(iter.Next())
+	a.Equal("RZPNX8", iter.Current().BookingReference)
+	a.Equal("90.80 GBP", iter.Current().TotalAmount().String())
+
+	a.True(iter.Next())
+	a.Equal("ABC123", iter.Current().BookingReference)
+
+	a.False(iter.Next())
+	a.NoError(iter.Err())
+	a.Equal(&ListMeta{Limit: 50}, iter.Meta())
+}
+
+func TestStreamDecoderMetaAfterData(t *testing.T) {
+	a := assert.New(t)
+
+	d, err := newStreamDecoder[Airline](strings.NewReader(`{
+		"data": [{"id": "arl_1", "name": "British Airways", "iata_code": "BA"}, {"id": "arl_2", "name": "Lufthansa \"LH\"", "iata_code": "LH"}],
+		"meta": {"after": "abc", "limit": 2}
+	}`))
+	a.NoError(err)
+	a.Nil(d.meta)
+
+	first, ok := d.next()
+	a.True(ok)
+	a.Equal("BA", first.IATACode)
+
+	second, ok := d.next()
+	a.True(ok)
+	a.Equal(`Lufthansa "LH"`, second.Name)
+
+	_, ok = d.next()
+	a.False(ok)
+	a.NoError(d.err)
+	a.Equal(&ListMeta{After: "abc", Limit: 2}, d.meta)
+}
+
+func TestStreamDecoderMalformed(t *testing.T) {
+	a := assert.New(t)
+
+	d, err := newStreamDecoder[Airline](strings.NewReader(`{"data": [{"id": "arl_1"}, {"id": `))
+	a.NoError(err)
+
+	_, ok := d.next()
+	a.True(ok)
+
+	_, ok = d.next()
+	a.False(ok)
+	a.Error(d.err)
+}